@@ -8,6 +8,7 @@ import (
 	"github.com/rbee3u/dpass/internal/dcoin/dogecoin"
 	"github.com/rbee3u/dpass/internal/dcoin/ethereum"
 	"github.com/rbee3u/dpass/internal/dcoin/mnemonic"
+	"github.com/rbee3u/dpass/internal/dcoin/seedqr"
 	"github.com/rbee3u/dpass/internal/dcoin/solana"
 	"github.com/rbee3u/dpass/internal/dcoin/sui"
 	"github.com/rbee3u/dpass/internal/dcoin/tron"
@@ -27,6 +28,7 @@ func newCmd() *cobra.Command {
 	cmd.SilenceErrors = true
 	cmd.AddCommand(
 		mnemonic.NewCmd(),
+		seedqr.NewCmd(),
 		bitcoin.NewCmd(),
 		ethereum.NewCmd(),
 		tron.NewCmd(),
@@ -6,7 +6,9 @@ import (
 
 	"github.com/rbee3u/dpass/internal/dpass/aes256"
 	"github.com/rbee3u/dpass/internal/dpass/qrcode"
+	"github.com/rbee3u/dpass/internal/dpass/qrdecode"
 	"github.com/rbee3u/dpass/internal/dpass/shamir"
+	"github.com/rbee3u/dpass/internal/dpass/sheet"
 	"github.com/spf13/cobra"
 )
 
@@ -26,7 +28,13 @@ func newCmd() *cobra.Command {
 		aes256.NewCmdDecrypt(),
 		shamir.NewCmdSplit(),
 		shamir.NewCmdCombine(),
+		shamir.NewCmdExtend(),
+		shamir.NewCmdReshard(),
+		shamir.NewCmdManifest(),
+		shamir.NewCmdDrill(),
 		qrcode.NewCmd(),
+		qrdecode.NewCmd(),
+		sheet.NewCmd(),
 	)
 	return cmd
 }
@@ -6,6 +6,7 @@ require (
 	github.com/spf13/cobra v1.10.2
 	golang.org/x/crypto v0.46.0
 	golang.org/x/term v0.38.0
+	golang.org/x/text v0.32.0
 	rsc.io/qr v0.2.0
 )
 
@@ -11,6 +11,7 @@ import (
 	"github.com/rbee3u/dpass/pkg/base58"
 	"github.com/rbee3u/dpass/pkg/bech32"
 	"github.com/rbee3u/dpass/pkg/bip3x"
+	"github.com/rbee3u/dpass/pkg/chains"
 	"github.com/rbee3u/dpass/pkg/hashx"
 	"github.com/rbee3u/dpass/pkg/secp256k1"
 	"github.com/spf13/cobra"
@@ -60,6 +61,8 @@ type backend struct {
 	index           uint32
 	secret          bool
 	decompress      bool
+	prompt          *dcoin.Passphrase
+	passphrase      string
 }
 
 func backendDefault() *backend {
@@ -71,6 +74,7 @@ func backendDefault() *backend {
 		index:      indexDefault,
 		secret:     secretDefault,
 		decompress: decompressDefault,
+		prompt:     dcoin.PassphraseDefault(),
 	}
 }
 
@@ -91,6 +95,7 @@ func NewCmd() *cobra.Command {
 		"show secret instead of address (default %t)", secretDefault))
 	cmd.Flags().BoolVar(&backend.decompress, "decompress", decompressDefault, fmt.Sprintf(
 		"decompress the public key or not (default %t)", decompressDefault))
+	backend.prompt.AddFlags(cmd)
 	return cmd
 }
 
@@ -164,6 +169,9 @@ func (b *backend) runE(_ *cobra.Command, _ []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to read mnemonic: %w", err)
 	}
+	if b.passphrase, err = b.prompt.Read(mnemonic); err != nil {
+		return fmt.Errorf("failed to read passphrase: %w", err)
+	}
 	result, err := b.getResult(mnemonic)
 	if err != nil {
 		return fmt.Errorf("failed to get result: %w", err)
@@ -178,7 +186,7 @@ func (b *backend) getResult(mnemonic string) (string, error) {
 	if err := b.checkArguments(); err != nil {
 		return "", fmt.Errorf("failed to check arguments: %w", err)
 	}
-	seed, err := bip3x.MnemonicToSeed(mnemonic, "")
+	seed, err := b.prompt.Seed(mnemonic, b.passphrase)
 	if err != nil {
 		return "", fmt.Errorf("failed to convert mnemonic to seed: %w", err)
 	}
@@ -210,10 +218,7 @@ func (b *backend) skToWIF(sk []byte) string {
 func (b *backend) pkToAddress(x, y *big.Int) string {
 	var data []byte
 	if !b.decompress {
-		data = make([]byte, 33)
-		data[0] = 2
-		x.FillBytes(data[1:33])
-		data[0] += byte(y.Bit(0))
+		data = chains.CompressPk(x, y)
 	} else {
 		data = make([]byte, 65)
 		data[0] = 4
@@ -1,12 +1,25 @@
 package dcoin
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"strings"
+
+	"github.com/rbee3u/dpass/internal/dpass"
+	"github.com/rbee3u/dpass/pkg/bip3x"
+	"github.com/spf13/cobra"
+)
+
+const (
+	passphraseDefault = false
+	confirmDefault    = false
 )
 
+var errPassphraseMismatch = errors.New("passphrases do not match")
+
 func ReadMnemonic() (string, error) {
 	mnemonic, err := io.ReadAll(os.Stdin)
 	if err != nil {
@@ -14,3 +27,71 @@ func ReadMnemonic() (string, error) {
 	}
 	return strings.Join(strings.Fields(string(mnemonic)), " "), nil
 }
+
+// Passphrase asks for the BIP39 passphrase on the terminal, it is never
+// taken from the command line where it would end up in the history.
+type Passphrase struct {
+	enabled bool
+	confirm bool
+	stderr  io.Writer
+	read    func(prompt string) ([]byte, error)
+	// seed is the one derived by Read for the fingerprint, together with
+	// what it was derived from.
+	seed       []byte
+	mnemonic   string
+	passphrase string
+}
+
+func PassphraseDefault() *Passphrase {
+	return &Passphrase{
+		enabled: passphraseDefault,
+		confirm: confirmDefault,
+		stderr:  os.Stderr,
+		read:    dpass.ReadPassword,
+	}
+}
+
+func (p *Passphrase) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&p.enabled, "passphrase", passphraseDefault, fmt.Sprintf(
+		"ask for the BIP39 passphrase and show the fingerprint of the master key (default %t)", passphraseDefault))
+	cmd.Flags().BoolVar(&p.confirm, "confirm-passphrase", confirmDefault, fmt.Sprintf(
+		"ask for the BIP39 passphrase twice, implies --passphrase (default %t)", confirmDefault))
+}
+
+// Read returns the passphrase, which is empty unless asked for. The
+// fingerprint goes to the standard error so that a mistyped passphrase, which
+// leads to another valid wallet, is noticed.
+func (p *Passphrase) Read(mnemonic string) (string, error) {
+	if !p.enabled && !p.confirm {
+		return "", nil
+	}
+	passphrase, err := p.read("Passphrase:")
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	if p.confirm {
+		again, err := p.read("Passphrase Again:")
+		if err != nil {
+			return "", fmt.Errorf("failed to read passphrase: %w", err)
+		}
+		if !bytes.Equal(passphrase, again) {
+			return "", errPassphraseMismatch
+		}
+	}
+	seed, err := bip3x.MnemonicToSeed(mnemonic, string(passphrase))
+	if err != nil {
+		return "", fmt.Errorf("failed to convert mnemonic to seed: %w", err)
+	}
+	_, _ = fmt.Fprintf(p.stderr, "Fingerprint: %x\n", bip3x.Secp256k1Fingerprint(seed))
+	p.seed, p.mnemonic, p.passphrase = seed, mnemonic, string(passphrase)
+	return string(passphrase), nil
+}
+
+// Seed converts the mnemonic to the seed, the one derived by Read is reused
+// since the 2048 rounds of PBKDF2 are slow by design.
+func (p *Passphrase) Seed(mnemonic, passphrase string) ([]byte, error) {
+	if p.seed != nil && p.mnemonic == mnemonic && p.passphrase == passphrase {
+		return p.seed, nil
+	}
+	return bip3x.MnemonicToSeed(mnemonic, passphrase)
+}
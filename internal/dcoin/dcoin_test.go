@@ -0,0 +1,73 @@
+package dcoin
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/rbee3u/dpass/pkg/bip3x"
+)
+
+func TestPassphrase(t *testing.T) {
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	tests := []struct {
+		enabled     bool
+		confirm     bool
+		answers     []string
+		passphrase  string
+		fingerprint string
+		err         error
+	}{
+		{enabled: false, passphrase: "", fingerprint: ""},
+		{enabled: true, answers: []string{""}, passphrase: "", fingerprint: "Fingerprint: 73c5da0a\n"},
+		{enabled: true, answers: []string{"TREZOR"}, passphrase: "TREZOR", fingerprint: "Fingerprint: b4e3f5ed\n"},
+		{confirm: true, answers: []string{"TREZOR", "TREZOR"}, passphrase: "TREZOR", fingerprint: "Fingerprint: b4e3f5ed\n"},
+		{confirm: true, answers: []string{"TREZOR", "TREZ0R"}, err: errPassphraseMismatch},
+	}
+	for _, tt := range tests {
+		var stderr bytes.Buffer
+		p := PassphraseDefault()
+		p.enabled, p.confirm, p.stderr = tt.enabled, tt.confirm, &stderr
+		answers := tt.answers
+		p.read = func(string) ([]byte, error) {
+			answer := answers[0]
+			answers = answers[1:]
+			return []byte(answer), nil
+		}
+		passphrase, err := p.Read(mnemonic)
+		if !errors.Is(err, tt.err) {
+			t.Fatalf("got = %v, want = %v", err, tt.err)
+		}
+		if passphrase != tt.passphrase || stderr.String() != tt.fingerprint {
+			t.Errorf("got = %q %q, want = %q %q", passphrase, stderr.String(), tt.passphrase, tt.fingerprint)
+		}
+	}
+}
+
+func TestPassphraseSeed(t *testing.T) {
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	p := PassphraseDefault()
+	p.enabled, p.stderr = true, &bytes.Buffer{}
+	p.read = func(string) ([]byte, error) { return []byte("TREZOR"), nil }
+	passphrase, err := p.Read(mnemonic)
+	if err != nil {
+		t.Fatalf("failed to read passphrase: %v", err)
+	}
+	for _, passphrase := range []string{passphrase, ""} {
+		seed, err := p.Seed(mnemonic, passphrase)
+		if err != nil {
+			t.Fatalf("failed to get seed: %v", err)
+		}
+		want, err := bip3x.MnemonicToSeed(mnemonic, passphrase)
+		if err != nil {
+			t.Fatalf("failed to convert mnemonic to seed: %v", err)
+		}
+		if !bytes.Equal(seed, want) {
+			t.Errorf("got = %x, want = %x", seed, want)
+		}
+		// The seed derived for the fingerprint is reused rather than derived again.
+		if reused := &seed[0] == &p.seed[0]; reused != (passphrase == "TREZOR") {
+			t.Errorf("got = %v, want = %v", reused, passphrase == "TREZOR")
+		}
+	}
+}
@@ -3,13 +3,13 @@ package dogecoin
 import (
 	"errors"
 	"fmt"
-	"math/big"
 	"os"
 	"slices"
 
 	"github.com/rbee3u/dpass/internal/dcoin"
 	"github.com/rbee3u/dpass/pkg/base58"
 	"github.com/rbee3u/dpass/pkg/bip3x"
+	"github.com/rbee3u/dpass/pkg/chains"
 	"github.com/rbee3u/dpass/pkg/hashx"
 	"github.com/rbee3u/dpass/pkg/secp256k1"
 	"github.com/spf13/cobra"
@@ -33,12 +33,14 @@ var (
 )
 
 type backend struct {
-	purpose uint32
-	coin    uint32
-	account uint32
-	change  uint32
-	index   uint32
-	secret  bool
+	purpose    uint32
+	coin       uint32
+	account    uint32
+	change     uint32
+	index      uint32
+	secret     bool
+	prompt     *dcoin.Passphrase
+	passphrase string
 }
 
 func backendDefault() *backend {
@@ -49,6 +51,7 @@ func backendDefault() *backend {
 		change:  changeDefault,
 		index:   indexDefault,
 		secret:  secretDefault,
+		prompt:  dcoin.PassphraseDefault(),
 	}
 }
 
@@ -63,6 +66,7 @@ func NewCmd() *cobra.Command {
 		"index number of address (default %v)", indexDefault))
 	cmd.Flags().BoolVar(&backend.secret, "secret", secretDefault, fmt.Sprintf(
 		"show secret instead of address (default %t)", secretDefault))
+	backend.prompt.AddFlags(cmd)
 	return cmd
 }
 
@@ -90,6 +94,9 @@ func (b *backend) runE(_ *cobra.Command, _ []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to read mnemonic: %w", err)
 	}
+	if b.passphrase, err = b.prompt.Read(mnemonic); err != nil {
+		return fmt.Errorf("failed to read passphrase: %w", err)
+	}
 	result, err := b.getResult(mnemonic)
 	if err != nil {
 		return fmt.Errorf("failed to get result: %w", err)
@@ -104,7 +111,7 @@ func (b *backend) getResult(mnemonic string) (string, error) {
 	if err := b.checkArguments(); err != nil {
 		return "", fmt.Errorf("failed to check arguments: %w", err)
 	}
-	seed, err := bip3x.MnemonicToSeed(mnemonic, "")
+	seed, err := b.prompt.Seed(mnemonic, b.passphrase)
 	if err != nil {
 		return "", fmt.Errorf("failed to convert mnemonic to seed: %w", err)
 	}
@@ -121,7 +128,7 @@ func (b *backend) getResult(mnemonic string) (string, error) {
 	if b.secret {
 		return skToWIF(sk), nil
 	}
-	return pkToAddress(secp256k1.S256().ScalarBaseMult(sk)), nil
+	return chains.DogecoinAddress(secp256k1.S256().ScalarBaseMult(sk)), nil
 }
 
 func skToWIF(sk []byte) string {
@@ -129,18 +136,3 @@ func skToWIF(sk []byte) string {
 	digest := hashx.Sha256Sum(hashx.Sha256Sum(data))[:4]
 	return base58.Encode(slices.Concat(data, digest))
 }
-
-func pkToAddress(x, y *big.Int) string {
-	data := make([]byte, 33)
-	data[0] = 2
-	x.FillBytes(data[1:33])
-	data[0] += byte(y.Bit(0))
-	pkHash := hashx.RipeMD160Sum(hashx.Sha256Sum(data))
-	return pkHashToAddress44(pkHash)
-}
-
-func pkHashToAddress44(pkHash []byte) string {
-	data := slices.Concat([]byte{0x1e}, pkHash)
-	digest := hashx.Sha256Sum(hashx.Sha256Sum(data))[:4]
-	return base58.Encode(slices.Concat(data, digest))
-}
@@ -4,13 +4,11 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
-	"math/big"
 	"os"
-	"unicode"
 
 	"github.com/rbee3u/dpass/internal/dcoin"
 	"github.com/rbee3u/dpass/pkg/bip3x"
-	"github.com/rbee3u/dpass/pkg/hashx"
+	"github.com/rbee3u/dpass/pkg/chains"
 	"github.com/rbee3u/dpass/pkg/secp256k1"
 	"github.com/spf13/cobra"
 )
@@ -33,12 +31,14 @@ var (
 )
 
 type backend struct {
-	purpose uint32
-	coin    uint32
-	account uint32
-	change  uint32
-	index   uint32
-	secret  bool
+	purpose    uint32
+	coin       uint32
+	account    uint32
+	change     uint32
+	index      uint32
+	secret     bool
+	prompt     *dcoin.Passphrase
+	passphrase string
 }
 
 func backendDefault() *backend {
@@ -49,6 +49,7 @@ func backendDefault() *backend {
 		change:  changeDefault,
 		index:   indexDefault,
 		secret:  secretDefault,
+		prompt:  dcoin.PassphraseDefault(),
 	}
 }
 
@@ -63,6 +64,7 @@ func NewCmd() *cobra.Command {
 		"index number of address (default %v)", indexDefault))
 	cmd.Flags().BoolVar(&backend.secret, "secret", secretDefault, fmt.Sprintf(
 		"show secret instead of address (default %t)", secretDefault))
+	backend.prompt.AddFlags(cmd)
 	return cmd
 }
 
@@ -90,6 +92,9 @@ func (b *backend) runE(_ *cobra.Command, _ []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to read mnemonic: %w", err)
 	}
+	if b.passphrase, err = b.prompt.Read(mnemonic); err != nil {
+		return fmt.Errorf("failed to read passphrase: %w", err)
+	}
 	result, err := b.getResult(mnemonic)
 	if err != nil {
 		return fmt.Errorf("failed to get result: %w", err)
@@ -104,7 +109,7 @@ func (b *backend) getResult(mnemonic string) (string, error) {
 	if err := b.checkArguments(); err != nil {
 		return "", fmt.Errorf("failed to check arguments: %w", err)
 	}
-	seed, err := bip3x.MnemonicToSeed(mnemonic, "")
+	seed, err := b.prompt.Seed(mnemonic, b.passphrase)
 	if err != nil {
 		return "", fmt.Errorf("failed to convert mnemonic to seed: %w", err)
 	}
@@ -121,19 +126,5 @@ func (b *backend) getResult(mnemonic string) (string, error) {
 	if b.secret {
 		return hex.EncodeToString(sk), nil
 	}
-	return pkToAddress(secp256k1.S256().ScalarBaseMult(sk)), nil
-}
-
-func pkToAddress(x, y *big.Int) string {
-	pk := make([]byte, 64)
-	x.FillBytes(pk[:32])
-	y.FillBytes(pk[32:])
-	data := []byte(hex.EncodeToString(hashx.Keccak256Sum(pk)[12:]))
-	digest := hashx.Keccak256Sum(data)
-	for i := range data {
-		if ((digest[i/2]>>(4-i%2*4))&0b1000) != 0 && unicode.IsLower(rune(data[i])) {
-			data[i] = byte(unicode.ToUpper(rune(data[i])))
-		}
-	}
-	return "0x" + string(data)
+	return chains.EthereumAddress(secp256k1.S256().ScalarBaseMult(sk)), nil
 }
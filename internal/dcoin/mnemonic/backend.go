@@ -1,19 +1,48 @@
 package mnemonic
 
 import (
+	"bufio"
+	"crypto/rand"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"os"
+	"strings"
+	"syscall"
 
 	"github.com/rbee3u/dpass/pkg/bip3x"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+const (
+	diceDefault  = false
+	coinsDefault = false
+	xorDefault   = false
+)
+
+var (
+	errDiceAndCoins      = errors.New("dice and coins are mutually exclusive")
+	errInsufficientRolls = errors.New("insufficient rolls")
 )
 
 type backend struct {
-	size int
+	size     int
+	language string
+	dice     bool
+	coins    bool
+	xor      bool
 }
 
 func backendDefault() *backend {
-	return &backend{size: bip3x.EntropyBitsMax}
+	return &backend{
+		size:     bip3x.EntropyBitsMax,
+		language: bip3x.LanguageEnglish,
+		dice:     diceDefault,
+		coins:    coinsDefault,
+		xor:      xorDefault,
+	}
 }
 
 func NewCmd() *cobra.Command {
@@ -22,15 +51,30 @@ func NewCmd() *cobra.Command {
 	cmd.Flags().IntVarP(&backend.size, "size", "s", bip3x.EntropyBitsMax, fmt.Sprintf(
 		"size is the number of entropy bits, must be a multiple of %v and within [%v, %v]",
 		bip3x.EntropyBitsStep, bip3x.EntropyBitsMin, bip3x.EntropyBitsMax))
+	cmd.Flags().StringVarP(&backend.language, "language", "l", bip3x.LanguageEnglish, fmt.Sprintf(
+		"language of the wordlist (%s)", strings.Join(bip3x.Languages(), " | ")))
+	cmd.Flags().BoolVar(&backend.dice, "dice", diceDefault, fmt.Sprintf(
+		"create entropy from dice rolls (1 to 6) read from standard input (default %t)", diceDefault))
+	cmd.Flags().BoolVar(&backend.coins, "coins", coinsDefault, fmt.Sprintf(
+		"create entropy from coin flips (H or T) read from standard input (default %t)", coinsDefault))
+	cmd.Flags().BoolVar(&backend.xor, "xor", xorDefault, fmt.Sprintf(
+		"xor the entropy from rolls with system randomness, it is no longer reproducible (default %t)", xorDefault))
+	cmd.AddCommand(newCmdRepair(), newCmdLastWord())
 	return cmd
 }
 
 func (b *backend) runE(_ *cobra.Command, _ []string) error {
-	entropy, err := bip3x.CreateEntropyRandomly(b.size)
+	var entropy []byte
+	var err error
+	if b.dice || b.coins {
+		entropy, err = b.createEntropyFromRolls(os.Stdin, os.Stderr, term.IsTerminal(syscall.Stdin))
+	} else {
+		entropy, err = bip3x.CreateEntropyRandomly(b.size)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to create entropy randomly: %w", err)
+		return fmt.Errorf("failed to create entropy: %w", err)
 	}
-	mnemonic, err := bip3x.EntropyToMnemonic(entropy)
+	mnemonic, err := bip3x.EntropyToMnemonicIn(entropy, b.language)
 	if err != nil {
 		return fmt.Errorf("failed to convert entropy to mnemonic: %w", err)
 	}
@@ -39,3 +83,68 @@ func (b *backend) runE(_ *cobra.Command, _ []string) error {
 	}
 	return nil
 }
+
+// createEntropyFromRolls reads all the rolls at once, or line by line until
+// there are enough of them when they are typed on a terminal, where an empty
+// line stops early. The same rolls always give the same entropy unless it is
+// mixed with system randomness, which also makes up for too few rolls.
+func (b *backend) createEntropyFromRolls(stdin io.Reader, stderr io.Writer, interactive bool) ([]byte, error) {
+	if b.dice && b.coins {
+		return nil, errDiceAndCoins
+	}
+	sides := bip3x.CoinSides
+	if b.dice {
+		sides = bip3x.DiceSides
+	}
+	entropy, bits, err := bip3x.CreateEntropyFromRolls("", sides, b.size)
+	if err != nil {
+		return nil, err
+	}
+	var rolls strings.Builder
+	if interactive {
+		scanner := bufio.NewScanner(stdin)
+		for bits < b.size {
+			_, _ = fmt.Fprintf(stderr, "Rolls (%v of %v bits, about %v more rolls):",
+				bits, b.size, math.Ceil(float64(b.size-bits)/bip3x.RollBits(sides)))
+			if !scanner.Scan() || len(strings.TrimSpace(scanner.Text())) == 0 {
+				break
+			}
+			line := scanner.Text()
+			if _, _, err := bip3x.CreateEntropyFromRolls(line, sides, b.size); err != nil {
+				_, _ = fmt.Fprintf(stderr, "Rejected: %v\n", err)
+				continue
+			}
+			rolls.WriteString(line)
+			if entropy, bits, err = bip3x.CreateEntropyFromRolls(rolls.String(), sides, b.size); err != nil {
+				return nil, err
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read rolls: %w", err)
+		}
+	} else {
+		input, err := io.ReadAll(stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read rolls: %w", err)
+		}
+		if entropy, bits, err = bip3x.CreateEntropyFromRolls(string(input), sides, b.size); err != nil {
+			return nil, err
+		}
+	}
+	if bits < b.size {
+		_, _ = fmt.Fprintf(stderr, "Warning: only %v of %v bits come from the rolls\n", bits, b.size)
+		if !b.xor {
+			return nil, errInsufficientRolls
+		}
+	}
+	if b.xor {
+		mask := make([]byte, len(entropy))
+		if _, err := rand.Read(mask); err != nil {
+			return nil, fmt.Errorf("failed to read random: %w", err)
+		}
+		for i := range entropy {
+			entropy[i] ^= mask[i]
+		}
+	}
+	return entropy, nil
+}
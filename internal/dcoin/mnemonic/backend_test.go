@@ -0,0 +1,57 @@
+package mnemonic
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCreateEntropyFromRolls(t *testing.T) {
+	b := backendDefault()
+	b.size, b.dice = 128, true
+	rolls := strings.Repeat("1234 ", 16)
+	var stderr bytes.Buffer
+	entropy, err := b.createEntropyFromRolls(strings.NewReader(rolls), &stderr, false)
+	if err != nil {
+		t.Fatalf("failed to create entropy from rolls: %v", err)
+	}
+	if got, want := hex.EncodeToString(entropy), strings.Repeat("1b", 16); got != want {
+		t.Errorf("got = %v, want = %v", got, want)
+	}
+
+	interactive := "1234 1234\n1237\n" + strings.Repeat("1234", 14) + "\n"
+	entropy, err = b.createEntropyFromRolls(strings.NewReader(interactive), &stderr, true)
+	if err != nil {
+		t.Fatalf("failed to create entropy from rolls: %v", err)
+	}
+	if got, want := hex.EncodeToString(entropy), strings.Repeat("1b", 16); got != want {
+		t.Errorf("got = %v, want = %v", got, want)
+	}
+	if !strings.Contains(stderr.String(), "Rejected") {
+		t.Errorf("got = %q, want = %v", stderr.String(), "Rejected")
+	}
+
+	stderr.Reset()
+	if _, err := b.createEntropyFromRolls(strings.NewReader("1234"), &stderr, false); !errors.Is(err, errInsufficientRolls) {
+		t.Errorf("got = %v, want = %v", err, errInsufficientRolls)
+	}
+	if !strings.Contains(stderr.String(), "Warning") {
+		t.Errorf("got = %q, want = %v", stderr.String(), "Warning")
+	}
+
+	b.xor = true
+	mixed, err := b.createEntropyFromRolls(strings.NewReader("1234"), &stderr, false)
+	if err != nil {
+		t.Fatalf("failed to create entropy from rolls: %v", err)
+	}
+	if len(mixed) != 16 || bytes.Equal(mixed, make([]byte, 16)) {
+		t.Errorf("got = %x, want = %v", mixed, "random")
+	}
+
+	b.coins = true
+	if _, err := b.createEntropyFromRolls(strings.NewReader(""), &stderr, false); !errors.Is(err, errDiceAndCoins) {
+		t.Errorf("got = %v, want = %v", err, errDiceAndCoins)
+	}
+}
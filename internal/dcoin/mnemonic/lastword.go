@@ -0,0 +1,55 @@
+package mnemonic
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/rbee3u/dpass/internal/dcoin"
+	"github.com/rbee3u/dpass/pkg/bip3x"
+	"github.com/spf13/cobra"
+)
+
+type lastWordBackend struct {
+	language string
+}
+
+func lastWordBackendDefault() *lastWordBackend {
+	return &lastWordBackend{
+		language: bip3x.LanguageEnglish,
+	}
+}
+
+func newCmdLastWord() *cobra.Command {
+	backend := lastWordBackendDefault()
+	cmd := &cobra.Command{Use: "last-word", Args: cobra.NoArgs, RunE: backend.runE}
+	cmd.Flags().StringVarP(&backend.language, "language", "l", bip3x.LanguageEnglish, fmt.Sprintf(
+		"language of the wordlist (%s)", strings.Join(bip3x.Languages(), " | ")))
+	return cmd
+}
+
+// runE reads all the words but the last one from standard input, such as 11
+// or 23 words picked by hand.
+func (b *lastWordBackend) runE(_ *cobra.Command, _ []string) error {
+	mnemonic, err := dcoin.ReadMnemonic()
+	if err != nil {
+		return fmt.Errorf("failed to read mnemonic: %w", err)
+	}
+	return b.lastWords(os.Stdout, mnemonic)
+}
+
+// lastWords writes every valid last word with the entropy bits it implies,
+// the one to choose must come from an independent source such as coin flips.
+func (b *lastWordBackend) lastWords(w io.Writer, mnemonic string) error {
+	lastWords, err := bip3x.LastWordsIn(mnemonic, b.language)
+	if err != nil {
+		return fmt.Errorf("failed to get last words: %w", err)
+	}
+	for _, lastWord := range lastWords {
+		if _, err := fmt.Fprintf(w, "%0*b\t%s\n", lastWord.EntropyBits, lastWord.Entropy, lastWord.Word); err != nil {
+			return fmt.Errorf("failed to write last word: %w", err)
+		}
+	}
+	return nil
+}
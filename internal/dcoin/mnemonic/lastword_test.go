@@ -0,0 +1,32 @@
+package mnemonic
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rbee3u/dpass/pkg/bip3x"
+)
+
+func TestLastWords(t *testing.T) {
+	b := lastWordBackendDefault()
+	var buf bytes.Buffer
+	if err := b.lastWords(&buf, strings.Repeat("abandon ", 23)); err != nil {
+		t.Fatalf("failed to get last words: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 8 || lines[0] != "000\tart" {
+		t.Errorf("got = %q, want = %q", lines, "000\tart")
+	}
+	b.language = bip3x.LanguageSpanish
+	buf.Reset()
+	if err := b.lastWords(&buf, strings.Repeat("ábaco ", 11)); err != nil {
+		t.Fatalf("failed to get last words: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n"); len(lines) != 128 {
+		t.Errorf("got = %v, want = %v", len(lines), 128)
+	}
+	if err := b.lastWords(&buf, "abandon abandon"); err == nil {
+		t.Errorf("got = %v, want = %v", err, "error")
+	}
+}
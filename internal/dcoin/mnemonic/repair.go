@@ -0,0 +1,104 @@
+package mnemonic
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/rbee3u/dpass/internal/dcoin"
+	"github.com/rbee3u/dpass/pkg/bip3x"
+	"github.com/rbee3u/dpass/pkg/chains"
+	"github.com/spf13/cobra"
+)
+
+const (
+	chainDefault   = ""
+	addressDefault = ""
+	limitDefault   = 10
+)
+
+var (
+	errInvalidChain   = errors.New("invalid chain")
+	errMissingAddress = errors.New("missing address")
+	errInvalidLimit   = errors.New("invalid limit")
+	errNoRepair       = errors.New("no mnemonic matches")
+)
+
+type repairBackend struct {
+	language string
+	chain    string
+	address  string
+	limit    int
+}
+
+func repairBackendDefault() *repairBackend {
+	return &repairBackend{
+		language: bip3x.LanguageEnglish,
+		chain:    chainDefault,
+		address:  addressDefault,
+		limit:    limitDefault,
+	}
+}
+
+func newCmdRepair() *cobra.Command {
+	backend := repairBackendDefault()
+	cmd := &cobra.Command{Use: "repair", Args: cobra.NoArgs, RunE: backend.runE}
+	cmd.Flags().StringVarP(&backend.language, "language", "l", bip3x.LanguageEnglish, fmt.Sprintf(
+		"language of the wordlist (%s)", strings.Join(bip3x.Languages(), " | ")))
+	cmd.Flags().StringVar(&backend.chain, "chain", chainDefault, fmt.Sprintf(
+		"dcoin chain to confirm the candidates with (%s)", strings.Join(chains.Names(), " | ")))
+	cmd.Flags().StringVar(&backend.address, "address", addressDefault,
+		"expected address at the default path of the chain, without passphrase")
+	cmd.Flags().IntVarP(&backend.limit, "limit", "n", limitDefault, fmt.Sprintf(
+		"maximum number of candidates to show, show all if 0 (default %v)", limitDefault))
+	return cmd
+}
+
+// runE reads the damaged mnemonic from standard input, with "?" in place of
+// the words that cannot be read.
+func (b *repairBackend) runE(_ *cobra.Command, _ []string) error {
+	mnemonic, err := dcoin.ReadMnemonic()
+	if err != nil {
+		return fmt.Errorf("failed to read mnemonic: %w", err)
+	}
+	return b.repair(os.Stdout, mnemonic)
+}
+
+// repair writes the candidates with their edit distance, the closest first.
+func (b *repairBackend) repair(w io.Writer, mnemonic string) error {
+	if len(b.chain) != 0 && len(b.address) == 0 {
+		return errMissingAddress
+	}
+	deriveAddress, exist := chains.DeriveAddress[b.chain]
+	if len(b.chain) != 0 && !exist {
+		return errInvalidChain
+	}
+	if b.limit < 0 {
+		return errInvalidLimit
+	}
+	var confirm func(string) bool
+	if exist {
+		confirm = func(candidate string) bool {
+			address, err := deriveAddress(candidate)
+			return err == nil && address == b.address
+		}
+	}
+	repairs, err := bip3x.RepairMnemonicIn(mnemonic, b.language, confirm)
+	if err != nil {
+		return fmt.Errorf("failed to repair mnemonic: %w", err)
+	}
+	if len(repairs) == 0 {
+		return errNoRepair
+	}
+	if b.limit != 0 {
+		repairs = repairs[:min(b.limit, len(repairs))]
+	}
+	for _, repair := range repairs {
+		if _, err := fmt.Fprintf(w, "%v\t%s\n", repair.Distance, repair.Mnemonic); err != nil {
+			return fmt.Errorf("failed to write candidate: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package mnemonic
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rbee3u/dpass/pkg/bip3x"
+)
+
+func TestRepair(t *testing.T) {
+	mnemonic := "legal winner thank year wave sausage worth useful legal winner thank yellow"
+	b := repairBackendDefault()
+	b.chain, b.address = "ethereum", "0x58A57ed9d8d624cBD12e2C467D34787555bB1b25"
+	var buf bytes.Buffer
+	if err := b.repair(&buf, "legal winner thank year wave sausage worth useful legal winner thank ?"); err != nil {
+		t.Fatalf("failed to repair: %v", err)
+	}
+	if got, want := buf.String(), "0\t"+mnemonic+"\n"; got != want {
+		t.Errorf("got = %q, want = %q", got, want)
+	}
+
+	b = repairBackendDefault()
+	b.limit = 1
+	buf.Reset()
+	if err := b.repair(&buf, strings.Replace(mnemonic, "sausage", "sausge", 1)); err != nil {
+		t.Fatalf("failed to repair: %v", err)
+	}
+	if got, want := buf.String(), "1\t"+mnemonic+"\n"; got != want {
+		t.Errorf("got = %q, want = %q", got, want)
+	}
+
+	spanish, err := bip3x.EntropyToMnemonicIn(bytes.Repeat([]byte{0x7f}, 16), bip3x.LanguageSpanish)
+	if err != nil {
+		t.Fatalf("failed to convert entropy to mnemonic: %v", err)
+	}
+	sentence := strings.Fields(spanish)
+	b.language, sentence[0] = bip3x.LanguageSpanish, sentence[0][1:]
+	buf.Reset()
+	if err := b.repair(&buf, strings.Join(sentence, " ")); err != nil {
+		t.Fatalf("failed to repair: %v", err)
+	}
+	if got, want := buf.String(), "1\t"+spanish+"\n"; got != want {
+		t.Errorf("got = %q, want = %q", got, want)
+	}
+
+	b.chain = "ethereum"
+	if err := b.repair(&buf, mnemonic); !errors.Is(err, errMissingAddress) {
+		t.Errorf("got = %v, want = %v", err, errMissingAddress)
+	}
+	b.chain, b.address = "litecoin", "L"
+	if err := b.repair(&buf, mnemonic); !errors.Is(err, errInvalidChain) {
+		t.Errorf("got = %v, want = %v", err, errInvalidChain)
+	}
+}
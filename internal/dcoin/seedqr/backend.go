@@ -0,0 +1,292 @@
+package seedqr
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/rbee3u/dpass/internal/dcoin"
+	"github.com/rbee3u/dpass/pkg/bip3x"
+	"github.com/rbee3u/dpass/pkg/qrencode"
+	"github.com/spf13/cobra"
+	"rsc.io/qr"
+	"rsc.io/qr/coding"
+)
+
+const (
+	compactDefault = false
+	decodeDefault  = false
+
+	formatDefault = formatANSI
+	formatANSI    = "ansi"
+	formatPNG     = "png"
+	formatRaw     = "raw"
+
+	moduleDefault = 8
+	moduleMin     = 1
+	moduleMax     = 100
+
+	outputDefault = ""
+	fileMode      = 0o600
+
+	// quiet is the width of the quiet zone in modules, which is also what
+	// qr.Code.PNG draws.
+	quiet = 4
+	// digitsPerWord is the width of the zero padded index of each word.
+	digitsPerWord = 4
+)
+
+var (
+	errInvalidFormat = errors.New("invalid format")
+	errInvalidModule = errors.New("invalid module")
+	errInvalidWords  = errors.New("invalid number of words, must be 12 or 24")
+	errInvalidDigits = errors.New("invalid digits")
+	errInvalidIndex  = errors.New("invalid word index")
+)
+
+// versions maps the number of words to the version of the code, SeedQR
+// fixes them so that signers can rely on the size of the code.
+var versions = map[bool]map[int]coding.Version{
+	false: {12: 2, 24: 3},
+	true:  {12: 1, 24: 2},
+}
+
+type backend struct {
+	compact bool
+	decode  bool
+	format  string
+	module  int
+	output  string
+}
+
+func backendDefault() *backend {
+	return &backend{
+		compact: compactDefault,
+		decode:  decodeDefault,
+		format:  formatDefault,
+		module:  moduleDefault,
+		output:  outputDefault,
+	}
+}
+
+func NewCmd() *cobra.Command {
+	backend := backendDefault()
+	cmd := &cobra.Command{Use: "seedqr", Args: cobra.NoArgs, RunE: backend.runE}
+	cmd.Flags().BoolVar(&backend.compact, "compact", compactDefault, fmt.Sprintf(
+		"use CompactSeedQR which carries the entropy bytes instead of the word indexes (default %t)", compactDefault))
+	cmd.Flags().BoolVarP(&backend.decode, "decode", "d", decodeDefault, fmt.Sprintf(
+		"read the digits of SeedQR or the raw or hex entropy of CompactSeedQR and write the mnemonic "+
+			"(default %t)", decodeDefault))
+	cmd.Flags().StringVarP(&backend.format, "format", "f", formatDefault, fmt.Sprintf(
+		"output format (%q | %q | %q the digits or the hex entropy)", formatANSI, formatPNG, formatRaw))
+	cmd.Flags().IntVarP(&backend.module, "module", "m", moduleDefault, fmt.Sprintf(
+		"module size in pixels of png, must be in range [%v, %v]", moduleMin, moduleMax))
+	cmd.Flags().StringVarP(&backend.output, "output", "o", outputDefault,
+		"path of output file, use standard output if empty")
+	return cmd
+}
+
+func (b *backend) runE(_ *cobra.Command, _ []string) error {
+	var result []byte
+	var err error
+	if b.decode {
+		result, err = b.runDecode()
+	} else {
+		result, err = b.runEncode()
+	}
+	if err != nil {
+		return err
+	}
+	if len(b.output) == 0 {
+		_, err = os.Stdout.Write(result)
+	} else {
+		err = os.WriteFile(b.output, result, fileMode)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write result: %w", err)
+	}
+	return nil
+}
+
+func (b *backend) runEncode() ([]byte, error) {
+	if err := b.checkArguments(); err != nil {
+		return nil, fmt.Errorf("failed to check arguments: %w", err)
+	}
+	mnemonic, err := dcoin.ReadMnemonic()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mnemonic: %w", err)
+	}
+	code, payload, err := b.encode(mnemonic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode mnemonic: %w", err)
+	}
+	switch b.format {
+	case formatRaw:
+		if b.compact {
+			return []byte(hex.EncodeToString(payload)), nil
+		}
+		return payload, nil
+	case formatPNG:
+		code.Scale = b.module
+		return code.PNG(), nil
+	default:
+		return qrencode.Terminal{Quiet: quiet}.Draw(code), nil
+	}
+}
+
+func (b *backend) runDecode() ([]byte, error) {
+	input, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+	var mnemonic string
+	if b.compact {
+		mnemonic, err = decodeCompact(compactEntropy(input))
+	} else {
+		mnemonic, err = decodeStandard(strings.TrimSpace(string(input)))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode seedqr: %w", err)
+	}
+	return []byte(mnemonic), nil
+}
+
+func (b *backend) checkArguments() error {
+	switch b.format {
+	case formatANSI, formatPNG, formatRaw:
+	default:
+		return errInvalidFormat
+	}
+	if b.module < moduleMin || moduleMax < b.module {
+		return errInvalidModule
+	}
+	return nil
+}
+
+// encode returns the code along with its payload, the digits are in numeric
+// mode and the entropy bytes in byte mode, both at level L.
+func (b *backend) encode(mnemonic string) (*qr.Code, []byte, error) {
+	var payload []byte
+	var encoding coding.Encoding
+	var err error
+	if b.compact {
+		if payload, err = encodeCompact(mnemonic); err == nil {
+			encoding = coding.String(payload)
+		}
+	} else {
+		var digits string
+		if digits, err = encodeStandard(mnemonic); err == nil {
+			payload, encoding = []byte(digits), coding.Num(digits)
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	words := len(strings.Fields(mnemonic))
+	plan, err := coding.NewPlan(versions[b.compact][words], coding.L, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create plan: %w", err)
+	}
+	code, err := plan.Encode(encoding)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode payload: %w", err)
+	}
+	return &qr.Code{Bitmap: code.Bitmap, Size: code.Size, Stride: code.Stride, Scale: moduleDefault}, payload, nil
+}
+
+// encodeStandard writes the index of each word as four digits, the words may
+// be abbreviated to their first four letters.
+func encodeStandard(mnemonic string) (string, error) {
+	sentence := strings.Fields(mnemonic)
+	if _, exist := versions[false][len(sentence)]; !exist {
+		return "", errInvalidWords
+	}
+	var digits strings.Builder
+	for i, word := range sentence {
+		value, err := bip3x.WordToValue(word)
+		if err != nil {
+			return "", fmt.Errorf("failed to look up word: %w", err)
+		}
+		sentence[i] = bip3x.ValueToWord(value)
+		_, _ = fmt.Fprintf(&digits, "%0*d", digitsPerWord, value)
+	}
+	if _, err := bip3x.MnemonicToEntropy(strings.Join(sentence, " ")); err != nil {
+		return "", fmt.Errorf("failed to check mnemonic: %w", err)
+	}
+	return digits.String(), nil
+}
+
+func decodeStandard(digits string) (string, error) {
+	if _, exist := versions[false][len(digits)/digitsPerWord]; !exist || len(digits)%digitsPerWord != 0 {
+		return "", errInvalidDigits
+	}
+	sentence := make([]string, 0, len(digits)/digitsPerWord)
+	for i := 0; i < len(digits); i += digitsPerWord {
+		value, err := strconv.ParseUint(digits[i:i+digitsPerWord], 10, 16)
+		if err != nil {
+			return "", errInvalidDigits
+		}
+		if value >= 1<<bip3x.BitsPerWord {
+			return "", errInvalidIndex
+		}
+		sentence = append(sentence, bip3x.ValueToWord(uint32(value)))
+	}
+	mnemonic := strings.Join(sentence, " ")
+	if _, err := bip3x.MnemonicToEntropy(mnemonic); err != nil {
+		return "", fmt.Errorf("failed to check mnemonic: %w", err)
+	}
+	return mnemonic, nil
+}
+
+// encodeCompact returns the entropy, the checksum word is left out since it
+// is implied by the entropy.
+func encodeCompact(mnemonic string) ([]byte, error) {
+	sentence := strings.Fields(mnemonic)
+	if _, exist := versions[true][len(sentence)]; !exist {
+		return nil, errInvalidWords
+	}
+	for i, word := range sentence {
+		value, err := bip3x.WordToValue(word)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up word: %w", err)
+		}
+		sentence[i] = bip3x.ValueToWord(value)
+	}
+	entropy, err := bip3x.MnemonicToEntropy(strings.Join(sentence, " "))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check mnemonic: %w", err)
+	}
+	return entropy, nil
+}
+
+// compactEntropy returns the entropy carried by the input, which is either
+// the hex written by the raw format or the bytes of the code as they are
+// decoded by dpass qrdecode. Raw bytes are taken as they are, since any of
+// them may look like whitespace.
+func compactEntropy(input []byte) []byte {
+	if entropy, err := hex.DecodeString(strings.TrimSpace(string(input))); err == nil {
+		if _, exist := versions[true][entropyWords(len(entropy))]; exist {
+			return entropy
+		}
+	}
+	return input
+}
+
+func entropyWords(size int) int {
+	return size * bip3x.BitsPerByte * bip3x.SentenceBitsStep / bip3x.EntropyBitsStep / bip3x.BitsPerWord
+}
+
+func decodeCompact(entropy []byte) (string, error) {
+	if _, exist := versions[true][entropyWords(len(entropy))]; !exist {
+		return "", errInvalidWords
+	}
+	mnemonic, err := bip3x.EntropyToMnemonic(entropy)
+	if err != nil {
+		return "", fmt.Errorf("failed to convert entropy to mnemonic: %w", err)
+	}
+	return mnemonic, nil
+}
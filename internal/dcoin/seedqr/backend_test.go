@@ -0,0 +1,130 @@
+package seedqr
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rbee3u/dpass/pkg/qrdecode"
+)
+
+func TestBackend(t *testing.T) {
+	tests := []struct {
+		mnemonic  string
+		digits    string
+		entropy0x string
+		size      int
+		sizeSmall int
+	}{
+		{
+			mnemonic:  "forum undo fragile fade shy sign arrest garment culture tube off merit",
+			digits:    "073318950739065415961602009907670428187212261116",
+			entropy0x: "5bbd9d71a8ec7990831aff359d426545",
+			size:      25,
+			sizeSmall: 21,
+		},
+		{
+			mnemonic: "attack pizza motion avocado network gather crop fresh patrol unusual wild holiday " +
+				"candy pony ranch winter theme error hybrid van cereal salon goddess expire",
+			digits:    "011513251154012711900771041507421289190620080870026613431420201617920614089619290300152408010643",
+			entropy0x: "0e74b64107f94cc0ccfae6a13dcbec3662154fec67e0e00999c07892597d190a",
+			size:      29,
+			sizeSmall: 25,
+		},
+	}
+	for _, tt := range tests {
+		b := backendDefault()
+		code, payload, err := b.encode(tt.mnemonic)
+		if err != nil {
+			t.Fatalf("failed to encode: %v", err)
+		}
+		if string(payload) != tt.digits || code.Size != tt.size {
+			t.Errorf("got = %s (%v), want = %s (%v)", payload, code.Size, tt.digits, tt.size)
+		}
+		if got := decodeCode(t, code.Bitmap, code.Stride, code.Size); got != tt.digits {
+			t.Errorf("got = %s, want = %s", got, tt.digits)
+		}
+		mnemonic, err := decodeStandard(tt.digits)
+		if err != nil {
+			t.Fatalf("failed to decode: %v", err)
+		}
+		if mnemonic != tt.mnemonic {
+			t.Errorf("got = %s, want = %s", mnemonic, tt.mnemonic)
+		}
+
+		b.compact = true
+		code, payload, err = b.encode(tt.mnemonic)
+		if err != nil {
+			t.Fatalf("failed to encode: %v", err)
+		}
+		if hex.EncodeToString(payload) != tt.entropy0x || code.Size != tt.sizeSmall {
+			t.Errorf("got = %x (%v), want = %s (%v)", payload, code.Size, tt.entropy0x, tt.sizeSmall)
+		}
+		if got := decodeCode(t, code.Bitmap, code.Stride, code.Size); got != string(payload) {
+			t.Errorf("got = %x, want = %x", got, payload)
+		}
+		// The entropy is read raw as decoded from the code or as hex.
+		for _, input := range []string{string(payload), tt.entropy0x, tt.entropy0x + "\n"} {
+			mnemonic, err = decodeCompact(compactEntropy([]byte(input)))
+			if err != nil {
+				t.Fatalf("failed to decode: %v", err)
+			}
+			if mnemonic != tt.mnemonic {
+				t.Errorf("got = %s, want = %s", mnemonic, tt.mnemonic)
+			}
+		}
+	}
+}
+
+func TestCompactEntropy(t *testing.T) {
+	// Raw entropy may start or end with bytes that look like whitespace.
+	for _, entropy := range []string{strings.Repeat(" ", 16), "\n" + strings.Repeat("\x00", 30) + "\n"} {
+		if got := compactEntropy([]byte(entropy)); string(got) != entropy {
+			t.Errorf("got = %x, want = %x", got, entropy)
+		}
+	}
+}
+
+func decodeCode(t *testing.T, bitmap []byte, stride, size int) string {
+	t.Helper()
+	grid := make(qrdecode.Grid, size)
+	for y := range grid {
+		grid[y] = make([]bool, size)
+		for x := range grid[y] {
+			grid[y][x] = bitmap[y*stride+x/8]&(1<<(7-x%8)) != 0
+		}
+	}
+	payload, err := qrdecode.DecodeGrid(grid)
+	if err != nil {
+		t.Fatalf("failed to decode code: %v", err)
+	}
+	return string(payload)
+}
+
+func TestBackendInvalid(t *testing.T) {
+	b := backendDefault()
+	if _, _, err := b.encode("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon agent"); !errors.Is(err, errInvalidWords) {
+		t.Errorf("got = %v, want = %v", err, errInvalidWords)
+	}
+	if _, _, err := b.encode("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon"); err == nil {
+		t.Errorf("got = nil, want = error")
+	}
+	// Abbreviated words are expanded.
+	_, payload, err := b.encode("foru undo frag fade shy sign arre garm cult tube off meri")
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	if want := "073318950739065415961602009907670428187212261116"; string(payload) != want {
+		t.Errorf("got = %s, want = %s", payload, want)
+	}
+	if _, err := decodeStandard("073318950739065415961602009907670428187212269999"); !errors.Is(err, errInvalidIndex) {
+		t.Errorf("got = %v, want = %v", err, errInvalidIndex)
+	}
+	if _, err := decodeStandard("0733"); !errors.Is(err, errInvalidDigits) {
+		t.Errorf("got = %v, want = %v", err, errInvalidDigits)
+	}
+	if _, err := decodeCompact(make([]byte, 20)); !errors.Is(err, errInvalidWords) {
+		t.Errorf("got = %v, want = %v", err, errInvalidWords)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/rbee3u/dpass/internal/dcoin"
 	"github.com/rbee3u/dpass/pkg/base58"
 	"github.com/rbee3u/dpass/pkg/bip3x"
+	"github.com/rbee3u/dpass/pkg/chains"
 	"github.com/spf13/cobra"
 )
 
@@ -32,12 +33,14 @@ var (
 )
 
 type backend struct {
-	purpose uint32
-	coin    uint32
-	account uint32
-	change  int32
-	index   int32
-	secret  bool
+	purpose    uint32
+	coin       uint32
+	account    uint32
+	change     int32
+	index      int32
+	secret     bool
+	prompt     *dcoin.Passphrase
+	passphrase string
 }
 
 func backendDefault() *backend {
@@ -46,6 +49,7 @@ func backendDefault() *backend {
 		coin:    coinDefault,
 		account: accountDefault,
 		secret:  secretDefault,
+		prompt:  dcoin.PassphraseDefault(),
 	}
 }
 
@@ -60,6 +64,7 @@ func NewCmd() *cobra.Command {
 		"index number of address (default %v)", indexDefault))
 	cmd.Flags().BoolVar(&backend.secret, "secret", secretDefault, fmt.Sprintf(
 		"show secret instead of address (default %t)", secretDefault))
+	backend.prompt.AddFlags(cmd)
 	return cmd
 }
 
@@ -90,6 +95,9 @@ func (b *backend) runE(_ *cobra.Command, _ []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to read mnemonic: %w", err)
 	}
+	if b.passphrase, err = b.prompt.Read(mnemonic); err != nil {
+		return fmt.Errorf("failed to read passphrase: %w", err)
+	}
 	result, err := b.getResult(mnemonic)
 	if err != nil {
 		return fmt.Errorf("failed to get result: %w", err)
@@ -104,7 +112,7 @@ func (b *backend) getResult(mnemonic string) (string, error) {
 	if err := b.checkArguments(); err != nil {
 		return "", fmt.Errorf("failed to check arguments: %w", err)
 	}
-	seed, err := bip3x.MnemonicToSeed(mnemonic, "")
+	seed, err := b.prompt.Seed(mnemonic, b.passphrase)
 	if err != nil {
 		return "", fmt.Errorf("failed to convert mnemonic to seed: %w", err)
 	}
@@ -127,5 +135,5 @@ func (b *backend) getResult(mnemonic string) (string, error) {
 	if b.secret {
 		return base58.Encode(privateKey), nil
 	}
-	return base58.Encode(privateKey[ed25519.SeedSize:]), nil
+	return chains.SolanaAddress(privateKey[ed25519.SeedSize:]), nil
 }
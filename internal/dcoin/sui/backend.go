@@ -2,7 +2,6 @@ package sui
 
 import (
 	"crypto/ed25519"
-	"encoding/hex"
 	"errors"
 	"fmt"
 	"os"
@@ -11,7 +10,7 @@ import (
 	"github.com/rbee3u/dpass/internal/dcoin"
 	"github.com/rbee3u/dpass/pkg/bech32"
 	"github.com/rbee3u/dpass/pkg/bip3x"
-	"github.com/rbee3u/dpass/pkg/hashx"
+	"github.com/rbee3u/dpass/pkg/chains"
 	"github.com/spf13/cobra"
 )
 
@@ -35,12 +34,14 @@ var (
 )
 
 type backend struct {
-	purpose uint32
-	coin    uint32
-	account uint32
-	change  int32
-	index   int32
-	secret  bool
+	purpose    uint32
+	coin       uint32
+	account    uint32
+	change     int32
+	index      int32
+	secret     bool
+	prompt     *dcoin.Passphrase
+	passphrase string
 }
 
 func backendDefault() *backend {
@@ -51,6 +52,7 @@ func backendDefault() *backend {
 		change:  changeDefault,
 		index:   indexDefault,
 		secret:  secretDefault,
+		prompt:  dcoin.PassphraseDefault(),
 	}
 }
 
@@ -65,6 +67,7 @@ func NewCmd() *cobra.Command {
 		"index number of address (default %v)", indexDefault))
 	cmd.Flags().BoolVar(&backend.secret, "secret", secretDefault, fmt.Sprintf(
 		"show secret instead of address (default %t)", secretDefault))
+	backend.prompt.AddFlags(cmd)
 	return cmd
 }
 
@@ -95,6 +98,9 @@ func (b *backend) runE(_ *cobra.Command, _ []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to read mnemonic: %w", err)
 	}
+	if b.passphrase, err = b.prompt.Read(mnemonic); err != nil {
+		return fmt.Errorf("failed to read passphrase: %w", err)
+	}
 	result, err := b.getResult(mnemonic)
 	if err != nil {
 		return fmt.Errorf("failed to get result: %w", err)
@@ -109,7 +115,7 @@ func (b *backend) getResult(mnemonic string) (string, error) {
 	if err := b.checkArguments(); err != nil {
 		return "", fmt.Errorf("failed to check arguments: %w", err)
 	}
-	seed, err := bip3x.MnemonicToSeed(mnemonic, "")
+	seed, err := b.prompt.Seed(mnemonic, b.passphrase)
 	if err != nil {
 		return "", fmt.Errorf("failed to convert mnemonic to seed: %w", err)
 	}
@@ -132,13 +138,9 @@ func (b *backend) getResult(mnemonic string) (string, error) {
 	if b.secret {
 		return skToWIF(privateKey[:ed25519.SeedSize]), nil
 	}
-	return pkToAddress(privateKey[ed25519.SeedSize:]), nil
+	return chains.SuiAddress(privateKey[ed25519.SeedSize:]), nil
 }
 
 func skToWIF(sk []byte) string {
 	return bech32.Encode("suiprivkey", nil, slices.Concat([]byte{0}, sk))
 }
-
-func pkToAddress(pk []byte) string {
-	return "0x" + hex.EncodeToString(hashx.Blake2b256Sum(slices.Concat([]byte{0}, pk)))
-}
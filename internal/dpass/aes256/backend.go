@@ -6,6 +6,7 @@ import (
 	"crypto/cipher"
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -16,6 +17,8 @@ import (
 
 const gcmStandardNonceSize = 12
 
+var errCiphertextTooShort = errors.New("ciphertext too short")
+
 type encryptBackend struct {
 	nonceReader io.Reader
 }
@@ -105,6 +108,11 @@ func (b *decryptBackend) runE(_ *cobra.Command, _ []string) error {
 	return nil
 }
 
+// Decrypt decrypts the output of the encrypt command in memory.
+func Decrypt(key, encodedNonceAndCiphertext []byte) ([]byte, error) {
+	return decryptBackendDefault().decrypt(key, bytes.TrimSpace(encodedNonceAndCiphertext))
+}
+
 func (b *decryptBackend) decrypt(key, encodedNonceAndCiphertext []byte) ([]byte, error) {
 	block, err := aes.NewCipher(key)
 	if err != nil {
@@ -118,6 +126,9 @@ func (b *decryptBackend) decrypt(key, encodedNonceAndCiphertext []byte) ([]byte,
 	if _, err := hex.Decode(nonceAndCiphertext, encodedNonceAndCiphertext); err != nil {
 		return nil, fmt.Errorf("failed to decode nonce and ciphertext: %w", err)
 	}
+	if len(nonceAndCiphertext) < gcmStandardNonceSize+aead.Overhead() {
+		return nil, fmt.Errorf("failed to split nonce and ciphertext: %w", errCiphertextTooShort)
+	}
 	nonce := nonceAndCiphertext[:gcmStandardNonceSize]
 	ciphertext := nonceAndCiphertext[gcmStandardNonceSize:]
 	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
@@ -2,6 +2,7 @@ package aes256
 
 import (
 	"bytes"
+	"errors"
 	"io"
 	"testing"
 )
@@ -67,3 +68,13 @@ func TestDecryptBackend(t *testing.T) {
 		}
 	}
 }
+
+func TestDecryptBackendShort(t *testing.T) {
+	key := []byte("a7b2fa8897cf785e2e5dbca7648617d4")
+	for _, encodedNonceAndCiphertext := range []string{"", "6363", "636363363663313638303439", "63636336366331363830343989f0525931a606f3d22a1fb9248b24"} {
+		db := decryptBackendDefault()
+		if _, err := db.decrypt(key, []byte(encodedNonceAndCiphertext)); !errors.Is(err, errCiphertextTooShort) {
+			t.Fatalf("got = %v, want = %v", err, errCiphertextTooShort)
+		}
+	}
+}
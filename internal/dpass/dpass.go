@@ -1,6 +1,7 @@
 package dpass
 
 import (
+	"encoding/hex"
 	"fmt"
 	"os"
 	"syscall"
@@ -14,7 +15,7 @@ func ReadPassword(prompt string) (password []byte, err error) {
 	fileDescriptor := syscall.Stdin
 	if !term.IsTerminal(fileDescriptor) {
 		var terminal *os.File
-		if terminal, err = os.Open("/dev/tty"); err != nil {
+		if terminal, err = OpenTerminal(); err != nil {
 			return nil, fmt.Errorf("failed to open terminal: %w", err)
 		}
 		defer func() {
@@ -31,9 +32,32 @@ func ReadPassword(prompt string) (password []byte, err error) {
 	return password, nil
 }
 
+// OpenTerminal opens the controlling terminal, so that the operator can still
+// be asked for input while the standard input is redirected.
+func OpenTerminal() (*os.File, error) {
+	terminal, err := os.Open("/dev/tty")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open /dev/tty: %w", err)
+	}
+	return terminal, nil
+}
+
 // DeriveKey derives a key from the password using Argon2id key derivation function.
 // The salt, cost parameters and length of key are hardcoded, don't modify them!!!!!
 func DeriveKey(password []byte) []byte {
 	salt := []byte("github.com/rbee3u/dpass/internal/dpass.DeriveKey")
 	return argon2.IDKey(password, salt, 16, 1*1024*1024, 2, 32)
 }
+
+// FingerprintSaltSize is the size of the random salt of a fingerprint.
+const FingerprintSaltSize = 16
+
+// Fingerprint identifies a secret without revealing it, so that a recovered
+// secret can be compared with the one recorded when it was split. It is the
+// hex of 16 bytes of Argon2id over the secret and a random salt, which makes
+// every guess at a secret with low entropy costly. The Argon2id parameters
+// are fixed, so that a fingerprint stored in a manifest along with its salt
+// can be checked again years later.
+func Fingerprint(secret, salt []byte) string {
+	return hex.EncodeToString(argon2.IDKey(secret, salt, 3, 64*1024, 4, 16))
+}
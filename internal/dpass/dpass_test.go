@@ -28,3 +28,33 @@ func TestDeriveKey(t *testing.T) {
 		}
 	}
 }
+
+func TestFingerprint(t *testing.T) {
+	tests := []struct {
+		secret      []byte
+		salt        []byte
+		fingerprint string
+	}{
+		{
+			secret:      []byte("_Short"),
+			salt:        []byte("ccc66c168049ccc6"),
+			fingerprint: "aff679c9be56f11ec05532ed9304868f",
+		},
+		{
+			secret:      []byte("_Short"),
+			salt:        []byte("ccc66c168049ccc7"),
+			fingerprint: "7bf455b59d8394b591466c1079e9e97c",
+		},
+		{
+			secret:      []byte("_LongLongLongLongLongLongLongLongLongLongLongLongLongLongLongLong"),
+			salt:        []byte("ccc66c168049ccc6"),
+			fingerprint: "5e77e3e19c3af54c31c6d259056f3e43",
+		},
+	}
+	for _, tt := range tests {
+		fingerprint := dpass.Fingerprint(tt.secret, tt.salt)
+		if fingerprint != tt.fingerprint {
+			t.Errorf("got = %s, want = %s", fingerprint, tt.fingerprint)
+		}
+	}
+}
@@ -1,11 +1,18 @@
 package qrcode
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"image/color"
 	"io"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/rbee3u/dpass/pkg/bech32"
+	"github.com/rbee3u/dpass/pkg/qrencode"
+	"github.com/rbee3u/dpass/pkg/ur"
 	"github.com/spf13/cobra"
 	"rsc.io/qr"
 )
@@ -21,26 +28,84 @@ const (
 	quietMin     = 0
 	quietMax     = 9
 
-	swapDefault = false
+	swapDefault    = false
+	noColorDefault = false
+
+	formatDefault = formatANSI
+	formatANSI    = "ansi"
+	formatHalf    = "half"
+	formatTXT     = "txt"
+	formatPNG     = "png"
+	formatSVG     = "svg"
+	formatSixel   = "sixel"
+	formatKitty   = "kitty"
+	formatAuto    = "auto"
+
+	moduleDefault = 8
+	moduleMin     = 1
+	moduleMax     = 100
+
+	foregroundDefault = "#000000"
+	backgroundDefault = "#ffffff"
+
+	outputDefault = ""
+	fileMode      = 0o600
+
+	upperDefault    = false
+	bitcoinScheme   = "bitcoin:"
+	urDefault       = false
+	fragmentDefault = 200
+	framesDefault   = 0
+	intervalDefault = 400 * time.Millisecond
 )
 
 var (
-	errInvalidLevel = errors.New("invalid level")
-	errInvalidQuiet = errors.New("invalid quiet")
+	errInvalidLevel  = errors.New("invalid level")
+	errInvalidQuiet  = errors.New("invalid quiet")
+	errInvalidFormat = errors.New("invalid format")
+	errInvalidModule = errors.New("invalid module")
+	errInvalidColor  = errors.New("invalid color")
+	errInvalidFrag   = errors.New("invalid fragment")
+	errInvalidFrames = errors.New("invalid frames")
+	errMissingOutput = errors.New("missing output for multiple images")
 )
 
 type backend struct {
-	level    string
-	levelInt qr.Level
-	quiet    int
-	swap     bool
+	level         string
+	levelInt      qr.Level
+	quiet         int
+	swap          bool
+	noColor       bool
+	format        string
+	module        int
+	foreground    string
+	foregroundRGB color.RGBA
+	background    string
+	backgroundRGB color.RGBA
+	output        string
+	upper         bool
+	ur            bool
+	fragment      int
+	frames        int
+	interval      time.Duration
 }
 
 func backendDefault() *backend {
 	return &backend{
-		level: levelDefault,
-		quiet: quietDefault,
-		swap:  swapDefault,
+		level:      levelDefault,
+		quiet:      quietDefault,
+		swap:       swapDefault,
+		noColor:    noColorDefault,
+		format:     formatDefault,
+		module:     moduleDefault,
+		foreground: foregroundDefault,
+		background: backgroundDefault,
+		output:     outputDefault,
+		upper:      upperDefault,
+		ur:         urDefault,
+		fragment:   fragmentDefault,
+		frames:     framesDefault,
+		interval:   intervalDefault,
 	}
 }
 
@@ -53,6 +118,32 @@ func NewCmd() *cobra.Command {
 		"quiet zone border size, must be in range [%v, %v]", quietMin, quietMax))
 	cmd.Flags().BoolVarP(&backend.swap, "swap", "s", swapDefault, fmt.Sprintf(
 		"swap black and white pixels (default %t)", swapDefault))
+	cmd.Flags().StringVarP(&backend.format, "format", "f", formatDefault, fmt.Sprintf(
+		"output format (%q | %q two rows per line | %q | %q | %q | %q | %q | %q picks kitty or sixel "+
+			"if the terminal supports them, ansi otherwise)",
+		formatANSI, formatHalf, formatTXT, formatPNG, formatSVG, formatSixel, formatKitty, formatAuto))
+	cmd.Flags().BoolVar(&backend.noColor, "no-color", noColorDefault, fmt.Sprintf(
+		"draw %q and %q with block characters instead of escape sequences, dark modules take "+
+			"the text color so swap on dark terminals (default %t)", formatANSI, formatHalf, noColorDefault))
+	cmd.Flags().IntVarP(&backend.module, "module", "m", moduleDefault, fmt.Sprintf(
+		"module size in pixels of png, svg, sixel and kitty, must be in range [%v, %v]", moduleMin, moduleMax))
+	cmd.Flags().StringVar(&backend.foreground, "foreground", foregroundDefault,
+		"color of dark modules of png, svg, sixel and kitty like \"#rrggbb\"")
+	cmd.Flags().StringVar(&backend.background, "background", backgroundDefault,
+		"color of light modules and quiet zone of png, svg, sixel and kitty like \"#rrggbb\"")
+	cmd.Flags().StringVarP(&backend.output, "output", "o", outputDefault,
+		"path of output file, use standard output if empty")
+	cmd.Flags().BoolVar(&backend.upper, "upper-bech32", upperDefault, fmt.Sprintf(
+		"uppercase a bech32 address, optionally after %q, so that it fits the smaller alphanumeric mode "+
+			"(default %t)", bitcoinScheme, upperDefault))
+	cmd.Flags().BoolVar(&backend.ur, "ur", urDefault, fmt.Sprintf(
+		"split the text into fountain coded \"ur:bytes\" parts, one code per part (default %t)", urDefault))
+	cmd.Flags().IntVar(&backend.fragment, "fragment", fragmentDefault, fmt.Sprintf(
+		"maximum bytes of text carried by each part, must be at least %v", ur.MinFragmentLen))
+	cmd.Flags().IntVar(&backend.frames, "frames", framesDefault,
+		"number of parts to write, use the number of fragments if 0, the terminal loops forever")
+	cmd.Flags().DurationVar(&backend.interval, "interval", intervalDefault,
+		"time each part stays on the terminal")
 	return cmd
 }
 
@@ -64,16 +155,41 @@ func (b *backend) runE(_ *cobra.Command, _ []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to read text: %w", err)
 	}
-	code, err := qr.Encode(string(text), b.levelInt)
+	if b.upper {
+		text = upperBech32(text)
+	}
+	if b.ur {
+		return b.runMultipart(text)
+	}
+	code, err := qrencode.Encode(string(text), b.levelInt)
 	if err != nil {
 		return fmt.Errorf("failed to encode text: %w", err)
 	}
-	if _, err := os.Stdout.Write(b.transformCode(code)); err != nil {
-		return fmt.Errorf("failed to write transformed code: %w", err)
+	data, err := b.renderCode(code)
+	if err != nil {
+		return fmt.Errorf("failed to render code: %w", err)
+	}
+	if len(b.output) == 0 {
+		_, err = os.Stdout.Write(data)
+	} else {
+		err = os.WriteFile(b.output, data, fileMode)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write rendered code: %w", err)
 	}
 	return nil
 }
 
+// upperBech32 uppercases the text when it is a bech32 address, which is case
+// insensitive, the surrounding whitespace is left alone.
+func upperBech32(text []byte) []byte {
+	address := strings.TrimPrefix(strings.TrimSpace(string(text)), bitcoinScheme)
+	if !bech32.Valid(address) {
+		return text
+	}
+	return bytes.ToUpper(text)
+}
+
 func (b *backend) checkArguments() error {
 	switch b.level {
 	case levelL:
@@ -90,20 +206,62 @@ func (b *backend) checkArguments() error {
 	if b.quiet < quietMin || quietMax < b.quiet {
 		return errInvalidQuiet
 	}
+	switch b.format {
+	case formatANSI, formatHalf, formatTXT, formatPNG, formatSVG, formatSixel, formatKitty:
+	case formatAuto:
+		// Only a terminal can be asked, anything else gets the ansi codes.
+		b.format = formatANSI
+		if len(b.output) == 0 {
+			b.format = detectGraphics()
+		}
+	default:
+		return errInvalidFormat
+	}
+	if b.module < moduleMin || moduleMax < b.module {
+		return errInvalidModule
+	}
+	var err error
+	if b.foregroundRGB, err = parseColor(b.foreground); err != nil {
+		return err
+	}
+	if b.backgroundRGB, err = parseColor(b.background); err != nil {
+		return err
+	}
+	if b.fragment < ur.MinFragmentLen {
+		return errInvalidFrag
+	}
+	if b.frames < 0 {
+		return errInvalidFrames
+	}
 	return nil
 }
 
-func (b *backend) transformCode(code *qr.Code) []byte {
-	var data []byte
-	for y := range b.quiet + code.Size + b.quiet {
-		for x := range b.quiet + code.Size + b.quiet {
-			if code.Black(x-b.quiet, y-b.quiet) != b.swap {
-				data = append(data, "\u001B[40m  "...)
-			} else {
-				data = append(data, "\u001B[47m  "...)
-			}
-		}
-		data = append(data, "\u001B[0m\n"...)
+func (b *backend) renderCode(code *qr.Code) ([]byte, error) {
+	switch b.format {
+	case formatTXT:
+		return b.transformText(code), nil
+	case formatPNG:
+		return b.transformPNG(code)
+	case formatSVG:
+		return b.transformSVG(code), nil
+	case formatHalf:
+		return b.terminal().DrawHalf(code), nil
+	case formatSixel:
+		return b.transformSixel(code), nil
+	case formatKitty:
+		return b.transformKitty(code)
+	default:
+		return b.terminal().Draw(code), nil
 	}
-	return data
+}
+
+// black tells whether a module of the code with its quiet zone is drawn as
+// dark, taking swap into account.
+func (b *backend) black(code *qr.Code, x, y int) bool {
+	return code.Black(x-b.quiet, y-b.quiet) != b.swap
+}
+
+// terminal draws the code for the ansi and half formats.
+func (b *backend) terminal() qrencode.Terminal {
+	return qrencode.Terminal{Quiet: b.quiet, Swap: b.swap, NoColor: b.noColor}
 }
@@ -0,0 +1,307 @@
+package qrcode
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image/png"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/rbee3u/dpass/pkg/ur"
+	"rsc.io/qr"
+)
+
+func TestBackendFormats(t *testing.T) {
+	code, err := qr.Encode("To be, or not to be, that is the question.", qr.M)
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	b := backendDefault()
+	b.format = formatPNG
+	b.module = 3
+	b.foreground = "#102030"
+	if err := b.checkArguments(); err != nil {
+		t.Fatalf("failed to check arguments: %v", err)
+	}
+	data, err := b.renderCode(code)
+	if err != nil {
+		t.Fatalf("failed to render code: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to decode png: %v", err)
+	}
+	size := (b.quiet + code.Size + b.quiet) * b.module
+	if img.Bounds().Dx() != size || img.Bounds().Dy() != size {
+		t.Fatalf("got = %v, want = %v", img.Bounds().Dx(), size)
+	}
+	for y := range code.Size {
+		for x := range code.Size {
+			r, g, _, _ := img.At((b.quiet+x)*b.module+1, (b.quiet+y)*b.module+2).RGBA()
+			if black := r>>8 == 0x10 && g>>8 == 0x20; black != code.Black(x, y) {
+				t.Fatalf("module (%v, %v) got = %v, want = %v", x, y, black, code.Black(x, y))
+			}
+		}
+	}
+
+	b.format = formatSVG
+	data, err = b.renderCode(code)
+	if err != nil {
+		t.Fatalf("failed to render code: %v", err)
+	}
+	if !bytes.Contains(data, []byte(`fill="#102030"`)) || !bytes.Contains(data, []byte(`fill="#ffffff"`)) {
+		t.Fatalf("got svg:\n%s", data)
+	}
+
+	b.format = formatTXT
+	b.quiet = 0
+	data, err = b.renderCode(code)
+	if err != nil {
+		t.Fatalf("failed to render code: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != code.Size || !strings.HasPrefix(lines[0], strings.Repeat("##", 7)+"  ") {
+		t.Fatalf("got txt:\n%s", data)
+	}
+}
+
+func TestBackendArguments(t *testing.T) {
+	tests := []struct {
+		format     string
+		module     int
+		foreground string
+		err        error
+	}{
+		{format: "gif", module: moduleDefault, foreground: foregroundDefault, err: errInvalidFormat},
+		{format: formatPNG, module: 0, foreground: foregroundDefault, err: errInvalidModule},
+		{format: formatPNG, module: moduleDefault, foreground: "#12345", err: errInvalidColor},
+		{format: formatPNG, module: moduleDefault, foreground: "black", err: errInvalidColor},
+	}
+	for _, tt := range tests {
+		b := backendDefault()
+		b.format, b.module, b.foreground = tt.format, tt.module, tt.foreground
+		if err := b.checkArguments(); !errors.Is(err, tt.err) {
+			t.Errorf("got = %v, want = %v", err, tt.err)
+		}
+	}
+}
+
+func TestBackendHalf(t *testing.T) {
+	code, err := qr.Encode("To be, or not to be, that is the question.", qr.H)
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	b := backendDefault()
+	b.noColor, b.swap = true, true
+	for format, draw := range map[string]func(*qr.Code) []byte{
+		formatHalf: b.terminal().DrawHalf,
+		formatANSI: b.terminal().Draw,
+	} {
+		b.format = format
+		if err := b.checkArguments(); err != nil {
+			t.Fatalf("failed to check arguments: %v", err)
+		}
+		data, err := b.renderCode(code)
+		if err != nil {
+			t.Fatalf("failed to render code: %v", err)
+		}
+		if want := draw(code); !bytes.Equal(data, want) {
+			t.Errorf("%s: got:\n%s\nwant:\n%s", format, data, want)
+		}
+	}
+}
+
+func TestBackendMultipart(t *testing.T) {
+	text := bytes.Repeat([]byte("To be, or not to be, that is the question. "), 30)
+	b := backendDefault()
+	b.format = formatTXT
+	b.fragment = 100
+	b.frames = 40
+	if err := b.checkArguments(); err != nil {
+		t.Fatalf("failed to check arguments: %v", err)
+	}
+	encoder, err := ur.NewEncoder(text, b.fragment)
+	if err != nil {
+		t.Fatalf("failed to create encoder: %v", err)
+	}
+	frames, err := b.renderFrames(encoder)
+	if err != nil {
+		t.Fatalf("failed to render frames: %v", err)
+	}
+	if len(frames) != 40 || !strings.Contains(string(frames[0]), "part 1-13 ") {
+		t.Fatalf("got %v frames, first:\n%s", len(frames), frames[0])
+	}
+	// The parts decode back to the text even if the first ones are lost.
+	decoder := ur.NewDecoder()
+	again, _ := ur.NewEncoder(text, b.fragment)
+	for i := range frames {
+		part := again.NextPart()
+		if !strings.Contains(string(frames[i]), "part "+strings.Split(part, "/")[1]+" ") {
+			t.Fatalf("frame %v does not show part %v", i, part)
+		}
+		if i >= 4 {
+			if err := decoder.Receive(part); err != nil {
+				t.Fatalf("failed to receive part: %v", err)
+			}
+		}
+	}
+	if !decoder.Done() || !bytes.Equal(decoder.Data(), text) {
+		t.Fatalf("failed to decode parts")
+	}
+
+	var output bytes.Buffer
+	b.interval = 0
+	if err := b.animate(&output, encoder, 3); err != nil {
+		t.Fatalf("failed to animate: %v", err)
+	}
+	if strings.Count(output.String(), "\u001B[") != 2 {
+		t.Fatalf("expect cursor to move up between frames")
+	}
+	if got := framePath("out/qr.png", 7, 120); got != "out/qr-007.png" {
+		t.Fatalf("got = %v, want = %v", got, "out/qr-007.png")
+	}
+}
+
+func TestBackendGraphics(t *testing.T) {
+	code, err := qr.Encode("To be, or not to be, that is the question.", qr.M)
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	b := backendDefault()
+	b.format = formatSixel
+	b.module = 2
+	if err := b.checkArguments(); err != nil {
+		t.Fatalf("failed to check arguments: %v", err)
+	}
+	data, err := b.renderCode(code)
+	if err != nil {
+		t.Fatalf("failed to render code: %v", err)
+	}
+	size := (b.quiet + code.Size + b.quiet) * b.module
+	pixels := decodeSixel(t, data, size)
+	for y := range size {
+		for x := range size {
+			if want := b.black(code, x/b.module, y/b.module); pixels[y][x] != want {
+				t.Fatalf("pixel (%v, %v) got = %v, want = %v", x, y, pixels[y][x], want)
+			}
+		}
+	}
+
+	b.format = formatKitty
+	data, err = b.renderCode(code)
+	if err != nil {
+		t.Fatalf("failed to render code: %v", err)
+	}
+	var payload strings.Builder
+	for i, command := range strings.Split(strings.TrimSuffix(string(data), "\u001B\\\n"), "\u001B\\") {
+		control, chunk, _ := strings.Cut(strings.TrimPrefix(command, "\u001B_G"), ";")
+		if i == 0 && !strings.HasPrefix(control, "a=T,f=100,") {
+			t.Fatalf("got control = %v", control)
+		}
+		payload.WriteString(chunk)
+	}
+	raw, err := base64.StdEncoding.DecodeString(payload.String())
+	if err != nil {
+		t.Fatalf("failed to decode base64: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("failed to decode png: %v", err)
+	}
+	if img.Bounds().Dx() != size {
+		t.Fatalf("got = %v, want = %v", img.Bounds().Dx(), size)
+	}
+
+	// Nothing to ask when the output is a file.
+	b.format = formatAuto
+	b.output = "qr.txt"
+	if err := b.checkArguments(); err != nil || b.format != formatANSI {
+		t.Fatalf("got = %v, want = %v", b.format, formatANSI)
+	}
+}
+
+// decodeSixel reads back the images written by transformSixel, with the
+// color register 1 as dark.
+func decodeSixel(t *testing.T, data []byte, size int) [][]bool {
+	t.Helper()
+	body, ok := strings.CutPrefix(string(data), fmt.Sprintf("\u001BPq\"1;1;%v;%v", size, size))
+	if !ok || !strings.HasSuffix(body, "\u001B\\\n") {
+		t.Fatalf("got sixel:\n%q", data)
+	}
+	pixels := make([][]bool, size)
+	for y := range pixels {
+		pixels[y] = make([]bool, size)
+	}
+	top, x, dark := 0, 0, false
+	for i := 0; i < len(body); i++ {
+		switch c := body[i]; {
+		case c == '#':
+			j := i + 1
+			for j < len(body) && (body[j] >= '0' && body[j] <= '9' || body[j] == ';') {
+				j++
+			}
+			dark, i = body[i+1] == '1', j-1
+		case c == '$':
+			x = 0
+		case c == '-':
+			top, x = top+6, 0
+		case c == '!' || c >= '?' && c <= '~':
+			run := 1
+			if c == '!' {
+				j := i + 1
+				for body[j] >= '0' && body[j] <= '9' {
+					j++
+				}
+				run, _ = strconv.Atoi(body[i+1 : j])
+				c, i = body[j], j
+			}
+			for range run {
+				for k := range 6 {
+					if (c-'?')&(1<<k) != 0 && top+k < size {
+						pixels[top+k][x] = dark
+					}
+				}
+				x++
+			}
+		}
+	}
+	return pixels
+}
+
+func TestParseGraphicsReply(t *testing.T) {
+	tests := []struct {
+		reply  string
+		format string
+	}{
+		{reply: "\u001B_Gi=31;OK\u001B\\\u001B[?62;22c", format: formatKitty},
+		{reply: "\u001B[?62;4;6;22c", format: formatSixel},
+		{reply: "\u001B[?4;6c", format: formatANSI},
+		{reply: "\u001B[?1;2c", format: formatANSI},
+		{reply: "", format: formatANSI},
+	}
+	for _, tt := range tests {
+		if got := parseGraphicsReply(tt.reply); got != tt.format {
+			t.Errorf("got = %v, want = %v", got, tt.format)
+		}
+	}
+}
+
+func TestUpperBech32(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", want: "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4"},
+		{text: "bitcoin:bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4\n", want: "BITCOIN:BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4\n"},
+		{text: "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5", want: "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5"},
+		{text: "1EtWjpCUf349JLZV5e4oTyg9EW8jk2wb9E", want: "1EtWjpCUf349JLZV5e4oTyg9EW8jk2wb9E"},
+	}
+	for _, tt := range tests {
+		if got := string(upperBech32([]byte(tt.text))); got != tt.want {
+			t.Errorf("got = %q, want = %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package qrcode
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image/color"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+	"rsc.io/qr"
+)
+
+const (
+	// kittyChunk is the largest payload of a kitty graphics command.
+	kittyChunk = 4096
+	// sixelBand is the number of pixel rows a sixel character covers.
+	sixelBand = 6
+	// graphicsQuery asks for the support of kitty graphics, then for the
+	// primary device attributes, which every terminal answers.
+	graphicsQuery   = "\u001B_Gi=31,s=1,v=1,a=q,t=d,f=24;AAAA\u001B\\\u001B[c"
+	graphicsTimeout = 300 * time.Millisecond
+)
+
+var (
+	kittyReply      = regexp.MustCompile("\u001B_Gi=31;OK")
+	attributesReply = regexp.MustCompile("\u001B\\[\\?([0-9;]*)c")
+)
+
+// transformSixel draws the code as a sixel image, each band of six pixel
+// rows is painted once per color with runs compressed.
+func (b *backend) transformSixel(code *qr.Code) []byte {
+	size := (b.quiet + code.Size + b.quiet) * b.module
+	var buf bytes.Buffer
+	_, _ = fmt.Fprintf(&buf, "\u001BPq\"1;1;%v;%v", size, size)
+	for i, c := range []color.RGBA{b.backgroundRGB, b.foregroundRGB} {
+		_, _ = fmt.Fprintf(&buf, "#%v;2;%v;%v;%v", i, percent(c.R), percent(c.G), percent(c.B))
+	}
+	row := make([]byte, size)
+	for top := 0; top < size; top += sixelBand {
+		for i, dark := range []bool{false, true} {
+			for x := range size {
+				bits := byte(0)
+				for k := range min(sixelBand, size-top) {
+					if b.black(code, x/b.module, (top+k)/b.module) == dark {
+						bits |= 1 << k
+					}
+				}
+				row[x] = '?' + bits
+			}
+			if i != 0 {
+				buf.WriteByte('$')
+			}
+			_, _ = fmt.Fprintf(&buf, "#%v", i)
+			writeSixelRuns(&buf, row)
+		}
+		buf.WriteByte('-')
+	}
+	buf.WriteString("\u001B\\\n")
+	return buf.Bytes()
+}
+
+func writeSixelRuns(buf *bytes.Buffer, row []byte) {
+	for x := 0; x < len(row); {
+		run := 1
+		for x+run < len(row) && row[x+run] == row[x] {
+			run++
+		}
+		if run > 3 {
+			_, _ = fmt.Fprintf(buf, "!%v%c", run, row[x])
+		} else {
+			buf.Write(row[x : x+run])
+		}
+		x += run
+	}
+}
+
+func percent(v uint8) int {
+	return (int(v)*100 + 0x7f) / 0xff
+}
+
+// transformKitty sends the png image through the kitty graphics protocol,
+// split into chunks as the protocol requires.
+func (b *backend) transformKitty(code *qr.Code) ([]byte, error) {
+	image, err := b.transformPNG(code)
+	if err != nil {
+		return nil, err
+	}
+	payload := base64.StdEncoding.EncodeToString(image)
+	var buf bytes.Buffer
+	for i := 0; i < len(payload); i += kittyChunk {
+		more := 0
+		if i+kittyChunk < len(payload) {
+			more = 1
+		}
+		if i == 0 {
+			_, _ = fmt.Fprintf(&buf, "\u001B_Ga=T,f=100,q=2,m=%v;", more)
+		} else {
+			_, _ = fmt.Fprintf(&buf, "\u001B_Gm=%v;", more)
+		}
+		buf.WriteString(payload[i:min(i+kittyChunk, len(payload))])
+		buf.WriteString("\u001B\\")
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// detectGraphics picks kitty or sixel when the terminal answers that it
+// supports them, and falls back to ansi when it does not or cannot be asked.
+func detectGraphics() string {
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		return formatANSI
+	}
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return formatANSI
+	}
+	defer func() { _ = tty.Close() }()
+	// Fd would switch the file to blocking mode and disable the deadline.
+	conn, err := tty.SyscallConn()
+	if err != nil {
+		return formatANSI
+	}
+	var state *term.State
+	_ = conn.Control(func(fd uintptr) { state, err = term.MakeRaw(int(fd)) })
+	if err != nil || state == nil {
+		return formatANSI
+	}
+	defer func() { _ = conn.Control(func(fd uintptr) { _ = term.Restore(int(fd), state) }) }()
+	if _, err := tty.WriteString(graphicsQuery); err != nil {
+		return formatANSI
+	}
+	if err := tty.SetReadDeadline(time.Now().Add(graphicsTimeout)); err != nil {
+		return formatANSI
+	}
+	var reply []byte
+	chunk := make([]byte, 256)
+	for !attributesReply.Match(reply) {
+		n, err := tty.Read(chunk)
+		if err != nil {
+			break
+		}
+		reply = append(reply, chunk[:n]...)
+	}
+	return parseGraphicsReply(string(reply))
+}
+
+// parseGraphicsReply prefers kitty, then sixel which is the attribute 4 of
+// the primary device attributes.
+func parseGraphicsReply(reply string) string {
+	if kittyReply.MatchString(reply) {
+		return formatKitty
+	}
+	if match := attributesReply.FindStringSubmatch(reply); match != nil {
+		attributes := strings.Split(match[1], ";")
+		for _, attribute := range attributes[min(1, len(attributes)):] {
+			if attribute == "4" {
+				return formatSixel
+			}
+		}
+	}
+	return formatANSI
+}
@@ -0,0 +1,85 @@
+package qrcode
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+
+	"rsc.io/qr"
+)
+
+// parseColor parses a color like "#rrggbb".
+func parseColor(value string) (color.RGBA, error) {
+	rgb, err := hex.DecodeString(strings.TrimPrefix(value, "#"))
+	if err != nil || len(rgb) != 3 {
+		return color.RGBA{}, errInvalidColor
+	}
+	return color.RGBA{R: rgb[0], G: rgb[1], B: rgb[2], A: 0xff}, nil
+}
+
+// transformText draws the code with plain characters for places where escape
+// sequences are not understood.
+func (b *backend) transformText(code *qr.Code) []byte {
+	var data []byte
+	for y := range b.quiet + code.Size + b.quiet {
+		for x := range b.quiet + code.Size + b.quiet {
+			if b.black(code, x, y) {
+				data = append(data, "##"...)
+			} else {
+				data = append(data, "  "...)
+			}
+		}
+		data = append(data, '\n')
+	}
+	return data
+}
+
+func (b *backend) transformPNG(code *qr.Code) ([]byte, error) {
+	size := (b.quiet + code.Size + b.quiet) * b.module
+	img := image.NewPaletted(image.Rect(0, 0, size, size), color.Palette{b.backgroundRGB, b.foregroundRGB})
+	for y := range size {
+		for x := range size {
+			if b.black(code, x/b.module, y/b.module) {
+				img.SetColorIndex(x, y, 1)
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode png: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// transformSVG draws the code in units of modules, the dark modules of each
+// row are merged into runs to keep the path short.
+func (b *backend) transformSVG(code *qr.Code) []byte {
+	size := b.quiet + code.Size + b.quiet
+	var path strings.Builder
+	for y := range size {
+		for x := 0; x < size; x++ {
+			start := x
+			for x < size && b.black(code, x, y) {
+				x++
+			}
+			if x > start {
+				_, _ = fmt.Fprintf(&path, "M%v %vh%vv1h-%vz", start, y, x-start, x-start)
+			}
+		}
+	}
+	var buf bytes.Buffer
+	_, _ = fmt.Fprintf(&buf, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n"+
+		"<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%v\" height=\"%v\" viewBox=\"0 0 %v %v\" "+
+		"shape-rendering=\"crispEdges\">\n", size*b.module, size*b.module, size, size)
+	_, _ = fmt.Fprintf(&buf, "<rect width=\"%v\" height=\"%v\" fill=\"%s\"/>\n", size, size, hexColor(b.backgroundRGB))
+	_, _ = fmt.Fprintf(&buf, "<path d=\"%s\" fill=\"%s\"/>\n</svg>\n", path.String(), hexColor(b.foregroundRGB))
+	return buf.Bytes()
+}
+
+func hexColor(c color.RGBA) string {
+	return "#" + hex.EncodeToString([]byte{c.R, c.G, c.B})
+}
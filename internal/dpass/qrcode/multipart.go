@@ -0,0 +1,112 @@
+package qrcode
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rbee3u/dpass/pkg/qrencode"
+	"github.com/rbee3u/dpass/pkg/ur"
+	"golang.org/x/term"
+)
+
+// runMultipart shows the parts in a loop on the terminal, which a wallet
+// scans as an animated code, or writes them as numbered frames.
+func (b *backend) runMultipart(text []byte) error {
+	encoder, err := ur.NewEncoder(text, b.fragment)
+	if err != nil {
+		return fmt.Errorf("failed to create encoder: %w", err)
+	}
+	terminal := b.format == formatANSI || b.format == formatHalf
+	if len(b.output) == 0 && terminal && b.frames == 0 && term.IsTerminal(int(os.Stdout.Fd())) {
+		return b.animate(os.Stdout, encoder, -1)
+	}
+	frames, err := b.renderFrames(encoder)
+	if err != nil {
+		return err
+	}
+	if len(b.output) == 0 {
+		if b.format == formatPNG || b.format == formatSVG {
+			return errMissingOutput
+		}
+		for _, frame := range frames {
+			if _, err := os.Stdout.Write(frame); err != nil {
+				return fmt.Errorf("failed to write frame: %w", err)
+			}
+		}
+		return nil
+	}
+	for i, frame := range frames {
+		if err := os.WriteFile(framePath(b.output, i+1, len(frames)), frame, fileMode); err != nil {
+			return fmt.Errorf("failed to write frame: %w", err)
+		}
+	}
+	return nil
+}
+
+// animate draws the parts over each other, after the pure parts it keeps
+// going with mixed ones so that a scanner missing some still completes.
+func (b *backend) animate(w io.Writer, encoder *ur.Encoder, count int) error {
+	height := 0
+	for i := 0; count < 0 || i < count; i++ {
+		frame, err := b.renderPart(encoder)
+		if err != nil {
+			return err
+		}
+		if height != 0 {
+			frame = append(fmt.Appendf(nil, "\u001B[%dA", height), frame...)
+		}
+		if _, err := w.Write(frame); err != nil {
+			return fmt.Errorf("failed to write frame: %w", err)
+		}
+		height = strings.Count(string(frame), "\n")
+		time.Sleep(b.interval)
+	}
+	return nil
+}
+
+func (b *backend) renderFrames(encoder *ur.Encoder) ([][]byte, error) {
+	count := b.frames
+	if count == 0 {
+		count = encoder.SeqLen()
+	}
+	frames := make([][]byte, count)
+	for i := range frames {
+		var err error
+		if frames[i], err = b.renderPart(encoder); err != nil {
+			return nil, err
+		}
+	}
+	return frames, nil
+}
+
+// renderPart renders the next part, the text formats are captioned with the
+// part they show. UR is case insensitive and scanners expect upper case.
+func (b *backend) renderPart(encoder *ur.Encoder) ([]byte, error) {
+	part := strings.ToUpper(encoder.NextPart())
+	code, err := qrencode.Encode(part, b.levelInt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode part: %w", err)
+	}
+	frame, err := b.renderCode(code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render code: %w", err)
+	}
+	if b.format == formatPNG || b.format == formatSVG {
+		return frame, nil
+	}
+	seq, _, _ := strings.Cut(strings.TrimPrefix(part, "UR:BYTES/"), "/")
+	if !strings.Contains(seq, "-") {
+		seq = "1-1"
+	}
+	return fmt.Appendf(frame, "part %-16s\n", seq), nil
+}
+
+// framePath numbers the frames before the extension of the output.
+func framePath(output string, index, count int) string {
+	ext := filepath.Ext(output)
+	return fmt.Sprintf("%s-%0*d%s", strings.TrimSuffix(output, ext), len(fmt.Sprint(count)), index, ext)
+}
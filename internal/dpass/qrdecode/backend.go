@@ -0,0 +1,124 @@
+package qrdecode
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/rbee3u/dpass/pkg/qrdecode"
+	"github.com/rbee3u/dpass/pkg/ur"
+	"github.com/spf13/cobra"
+)
+
+const (
+	rawDefault     = false
+	newlineDefault = false
+	urPrefix       = "ur:"
+)
+
+var errIncompleteUR = errors.New("incomplete ur parts")
+
+type backend struct {
+	raw     bool
+	newline bool
+}
+
+func backendDefault() *backend {
+	return &backend{raw: rawDefault, newline: newlineDefault}
+}
+
+func NewCmd() *cobra.Command {
+	backend := backendDefault()
+	cmd := &cobra.Command{Use: "qrdecode [image]...", Args: cobra.ArbitraryArgs, RunE: backend.runE}
+	cmd.Flags().BoolVar(&backend.raw, "raw", rawDefault, fmt.Sprintf(
+		"write the payload of each image, without joining \"ur:bytes\" parts (default %t)", rawDefault))
+	cmd.Flags().BoolVar(&backend.newline, "newline", newlineDefault, fmt.Sprintf(
+		"end each payload that is not joined with a newline if it lacks one (default %t)", newlineDefault))
+	return cmd
+}
+
+// runE decodes the png, jpeg or pgm images, or a single image from standard
+// input if there is none.
+func (b *backend) runE(_ *cobra.Command, args []string) error {
+	var payloads [][]byte
+	if len(args) == 0 {
+		payload, err := b.decodeImage(os.Stdin)
+		if err != nil {
+			return err
+		}
+		payloads = append(payloads, payload)
+	}
+	for _, path := range args {
+		payload, err := b.decodeFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to decode %s: %w", path, err)
+		}
+		payloads = append(payloads, payload)
+	}
+	output, err := b.join(payloads)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stdout.Write(output); err != nil {
+		return fmt.Errorf("failed to write payload: %w", err)
+	}
+	return nil
+}
+
+func (b *backend) decodeFile(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open image: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+	return b.decodeImage(file)
+}
+
+func (b *backend) decodeImage(r io.Reader) ([]byte, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	payload, err := qrdecode.Decode(img)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode qr code: %w", err)
+	}
+	return payload, nil
+}
+
+// join reassembles the message when the payloads are the "ur:bytes" parts
+// written by qrcode --ur, in any order and with duplicates, otherwise the
+// payloads are written one after another exactly as they are decoded.
+func (b *backend) join(payloads [][]byte) ([]byte, error) {
+	isUR := !b.raw
+	for _, payload := range payloads {
+		isUR = isUR && strings.HasPrefix(strings.ToLower(string(payload)), urPrefix)
+	}
+	if !isUR {
+		var output []byte
+		for _, payload := range payloads {
+			output = append(output, payload...)
+			if b.newline && !bytes.HasSuffix(payload, []byte("\n")) {
+				output = append(output, '\n')
+			}
+		}
+		return output, nil
+	}
+	decoder := ur.NewDecoder()
+	for _, payload := range payloads {
+		if err := decoder.Receive(string(payload)); err != nil {
+			return nil, fmt.Errorf("failed to receive part: %w", err)
+		}
+	}
+	if !decoder.Done() {
+		received, total := decoder.Progress()
+		return nil, fmt.Errorf("failed to join %v of %v fragments: %w", received, total, errIncompleteUR)
+	}
+	return decoder.Data(), nil
+}
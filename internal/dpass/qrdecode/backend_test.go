@@ -0,0 +1,129 @@
+package qrdecode
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rbee3u/dpass/pkg/ur"
+	"rsc.io/qr"
+)
+
+func writeImage(t *testing.T, path, text string) {
+	t.Helper()
+	code, err := qr.Encode(text, qr.L)
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	const scale, quiet = 4, 4
+	size := (code.Size + 2*quiet) * scale
+	img := image.NewGray(image.Rect(0, 0, size, size))
+	for y := range size {
+		for x := range size {
+			img.SetGray(x, y, color.Gray{Y: 255})
+			if mx, my := x/scale-quiet, y/scale-quiet; mx >= 0 && my >= 0 &&
+				mx < code.Size && my < code.Size && code.Black(mx, my) {
+				img.SetGray(x, y, color.Gray{Y: 0})
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("failed to encode png: %v", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatalf("failed to write image: %v", err)
+	}
+}
+
+func TestBackend(t *testing.T) {
+	dir := t.TempDir()
+	share := "-----BEGIN SHAMIR-----\nI: 0\nM: 2\nN: 3\n\nq83vEjRWeJq83vEjRWeJ\n-----END SHAMIR-----\n"
+	writeImage(t, filepath.Join(dir, "share.png"), share)
+	writeImage(t, filepath.Join(dir, "text.png"), "hello")
+
+	b := backendDefault()
+	var payloads [][]byte
+	for _, name := range []string{"share.png", "text.png"} {
+		payload, err := b.decodeFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("failed to decode %s: %v", name, err)
+		}
+		payloads = append(payloads, payload)
+	}
+	output, err := b.join(payloads)
+	if err != nil {
+		t.Fatalf("failed to join: %v", err)
+	}
+	if want := share + "hello"; string(output) != want {
+		t.Errorf("got = %q, want = %q", output, want)
+	}
+	b.newline = true
+	output, err = b.join(payloads)
+	if err != nil {
+		t.Fatalf("failed to join: %v", err)
+	}
+	if want := share + "hello\n"; string(output) != want {
+		t.Errorf("got = %q, want = %q", output, want)
+	}
+
+	if _, err := b.decodeImage(strings.NewReader("not an image")); err == nil {
+		t.Errorf("got = nil, want = error")
+	}
+}
+
+func TestBackendUR(t *testing.T) {
+	dir := t.TempDir()
+	message := []byte(strings.Repeat("a ciphertext split into several fountain coded parts ", 8))
+	encoder, err := ur.NewEncoder(message, 100)
+	if err != nil {
+		t.Fatalf("failed to create encoder: %v", err)
+	}
+	var paths []string
+	for i := range encoder.SeqLen() + 2 {
+		path := filepath.Join(dir, "part-"+string(rune('a'+i))+".png")
+		part := encoder.NextPart()
+		// The first part is lost, the extra ones make up for it.
+		if i == 0 {
+			continue
+		}
+		writeImage(t, path, strings.ToUpper(part))
+		paths = append(paths, path)
+	}
+
+	b := backendDefault()
+	var payloads [][]byte
+	for _, path := range paths {
+		payload, err := b.decodeFile(path)
+		if err != nil {
+			t.Fatalf("failed to decode %s: %v", path, err)
+		}
+		payloads = append(payloads, payload)
+	}
+	output, err := b.join(payloads)
+	if err != nil {
+		t.Fatalf("failed to join: %v", err)
+	}
+	if !bytes.Equal(output, message) {
+		t.Errorf("got = %q, want = %q", output, message)
+	}
+
+	if _, err := b.join(payloads[:1]); !errors.Is(err, errIncompleteUR) {
+		t.Errorf("got = %v, want = %v", err, errIncompleteUR)
+	}
+
+	b.raw = true
+	output, err = b.join(payloads[:1])
+	if err != nil {
+		t.Fatalf("failed to join: %v", err)
+	}
+	if want := string(payloads[0]); string(output) != want {
+		t.Errorf("got = %q, want = %q", output, want)
+	}
+}
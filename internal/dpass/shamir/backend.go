@@ -1,12 +1,18 @@
 package shamir
 
 import (
+	"crypto/rand"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"io"
 	"os"
-	"strconv"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/rbee3u/dpass/internal/dpass"
+	"github.com/rbee3u/dpass/pkg/shamir16"
 	"github.com/rbee3u/dpass/third_party/github.com/hashicorp/vault/shamir"
 	"github.com/spf13/cobra"
 )
@@ -15,20 +21,84 @@ const (
 	outputDefault    = ""
 	partsDefault     = 3
 	thresholdDefault = 2
+	countDefault     = 1
+	robustDefault    = false
+	interactDefault  = false
+	encryptDefault   = false
+	passwordsDefault = ""
+	fieldDefault     = fieldGF256
+	fieldGF256       = "gf256"
+	fieldGF65536     = "gf65536"
 	fileMode         = 0o600
 )
 
+// BlockType and the headers are the PEM encoding of a share, which is read by
+// other commands such as sheet as well.
+const (
+	BlockType       = "SHAMIR"
+	HeaderParts     = "N"
+	HeaderThreshold = "M"
+	HeaderIndex     = "I"
+	HeaderXs        = "X"
+	HeaderField     = "F"
+	HeaderSet       = "S"
+	HeaderChecksum  = "C"
+)
+
+const (
+	setIDSize    = 8
+	checksumSize = 4
+)
+
+var (
+	errInvalidBlockType  = errors.New("invalid block type")
+	errInvalidHeader     = errors.New("invalid header")
+	errInconsistentSet   = errors.New("inconsistent set")
+	errNotEnoughShares   = errors.New("not enough shares")
+	errInvalidCount      = errors.New("invalid count")
+	errNoCoordinateLeft  = errors.New("no coordinate left")
+	errMissingCoordinate = errors.New("missing coordinate")
+	errInvalidField      = errors.New("invalid field")
+	errUnsupportedField  = errors.New("unsupported field")
+	errChecksumMismatch  = errors.New("checksum mismatch")
+	errDuplicateShare    = errors.New("duplicate share")
+	errInteractiveFiles  = errors.New("interactive mode takes no file")
+	errMissingManifest   = errors.New("missing manifest")
+)
+
 type splitBackend struct {
-	output    string
-	parts     int
-	threshold int
+	output       string
+	parts        int
+	threshold    int
+	encrypt      bool
+	passwords    string
+	field        string
+	policy       string
+	manifest     string
+	holders      []string
+	locations    []string
+	format       string
+	randomReader io.Reader
+	nonceReader  io.Reader
+	deriveKey    func([]byte) []byte
+	now          func() time.Time
 }
 
 func splitBackendDefault() *splitBackend {
 	return &splitBackend{
-		output:    outputDefault,
-		parts:     partsDefault,
-		threshold: thresholdDefault,
+		output:       outputDefault,
+		parts:        partsDefault,
+		threshold:    thresholdDefault,
+		encrypt:      encryptDefault,
+		passwords:    passwordsDefault,
+		field:        fieldDefault,
+		policy:       policyDefault,
+		manifest:     manifestDefault,
+		format:       formatDefault,
+		randomReader: rand.Reader,
+		nonceReader:  rand.Reader,
+		deriveKey:    dpass.DeriveKey,
+		now:          time.Now,
 	}
 }
 
@@ -41,6 +111,23 @@ func NewCmdSplit() *cobra.Command {
 		"total number of shares to be split into")
 	cmd.Flags().IntVarP(&backend.threshold, "threshold", "m", thresholdDefault,
 		"minimum number of shares to reconstruct")
+	cmd.Flags().BoolVar(&backend.encrypt, "encrypt-shares", encryptDefault, fmt.Sprintf(
+		"encrypt every share with a password of its holder (default %t)", encryptDefault))
+	cmd.Flags().StringVar(&backend.passwords, "password-map", passwordsDefault,
+		"file of \"index:password\" lines used by --encrypt-shares, prompt for each share if empty")
+	cmd.Flags().StringVar(&backend.field, "field", fieldDefault, fmt.Sprintf(
+		"finite field of shares (%q up to 255 shares | %q up to %v shares)",
+		fieldGF256, fieldGF65536, shamir16.MaxParts))
+	cmd.Flags().StringVar(&backend.policy, "policy", policyDefault,
+		"access structure like \"or(ceo, 2of(d1, d2, d3), 4of(s1, s2, s3, s4, s5))\" instead of parts and threshold")
+	cmd.Flags().StringVar(&backend.manifest, "manifest", manifestDefault,
+		"path of manifest file, use \"<output>-manifest.json\" if empty and output is given")
+	cmd.Flags().StringSliceVar(&backend.holders, "holders", nil,
+		"names of share holders in order of index, recorded in the manifest")
+	cmd.Flags().StringSliceVar(&backend.locations, "locations", nil,
+		"locations of shares in order of index, recorded in the manifest")
+	cmd.Flags().StringVar(&backend.format, "format", formatDefault, fmt.Sprintf(
+		"encoding of shares (%q | %q to write down or engrave by hand)", formatPEM, formatWords))
 	return cmd
 }
 
@@ -49,63 +136,175 @@ func (b *splitBackend) runE(_ *cobra.Command, _ []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to read secret: %w", err)
 	}
+	return b.run(secret)
+}
+
+// run splits the secret and writes the shares, then the manifest.
+func (b *splitBackend) run(secret []byte) error {
+	if b.format != formatPEM && b.format != formatWords {
+		return errInvalidFormat
+	}
+	if b.format == formatWords && (b.encrypt || b.field != fieldGF256) {
+		return errUnsupportedFormat
+	}
+	if len(b.policy) != 0 {
+		if len(b.manifest) != 0 || b.format != formatPEM {
+			return errUnsupportedPolicy
+		}
+		blocks, err := b.splitPolicy(secret)
+		if err != nil {
+			return fmt.Errorf("failed to split: %w", err)
+		}
+		return writePolicyBlocks(b.output, blocks)
+	}
 	blocks, err := b.split(secret)
 	if err != nil {
 		return fmt.Errorf("failed to split: %w", err)
 	}
-	for index := range blocks {
-		if len(b.output) == 0 {
-			err = pem.Encode(os.Stdout, blocks[index])
-		} else {
-			path := fmt.Sprintf("%s-%v-%v-%v.txt", b.output, b.parts, b.threshold, index)
-			err = os.WriteFile(path, pem.EncodeToMemory(blocks[index]), fileMode)
+	if b.encrypt {
+		if blocks, err = b.sealBlocks(blocks); err != nil {
+			return fmt.Errorf("failed to encrypt shares: %w", err)
 		}
+	}
+	m, err := newManifest(secret, blocks, b.now(), b.randomReader, b.holders, b.locations)
+	if err != nil {
+		return fmt.Errorf("failed to create manifest: %w", err)
+	}
+	if b.format == formatWords {
+		err = writeWordShares(b.output, blocks)
+	} else {
+		err = writeBlocks(b.output, blocks)
+	}
+	if err != nil {
+		return err
+	}
+	if len(b.manifestPath()) == 0 {
+		return nil
+	}
+	return writeManifest(b.manifestPath(), m)
+}
+
+// manifestPath returns where the manifest is written, it is empty when the
+// shares go to standard output and no manifest is asked for.
+func (b *splitBackend) manifestPath() string {
+	if len(b.manifest) == 0 && len(b.output) != 0 {
+		return b.output + "-manifest.json"
+	}
+	return b.manifest
+}
+
+func (b *splitBackend) sealBlocks(blocks []*pem.Block) ([]*pem.Block, error) {
+	var passwords map[int][]byte
+	if len(b.passwords) != 0 {
+		var err error
+		if passwords, err = readPasswordMap(b.passwords); err != nil {
+			return nil, fmt.Errorf("failed to read password map: %w", err)
+		}
+	}
+	sealed := make([]*pem.Block, len(blocks))
+	for index, block := range blocks {
+		password, err := sharePassword(passwords, index)
 		if err != nil {
-			return fmt.Errorf("failed to write block: %w", err)
+			return nil, fmt.Errorf("failed to get password of share %v: %w", index, err)
+		}
+		if sealed[index], err = sealBlock(block, b.deriveKey(password), b.nonceReader); err != nil {
+			return nil, fmt.Errorf("failed to seal share %v: %w", index, err)
 		}
 	}
-	return nil
+	return sealed, nil
+}
+
+func sharePassword(passwords map[int][]byte, index int) ([]byte, error) {
+	if passwords == nil {
+		return dpass.ReadPassword(fmt.Sprintf("Password For Share %v:", index))
+	}
+	password, exist := passwords[index]
+	if !exist {
+		return nil, errMissingPassword
+	}
+	return password, nil
 }
 
 func (b *splitBackend) split(secret []byte) ([]*pem.Block, error) {
-	shares, err := shamir.Split(secret, b.parts, b.threshold)
+	var shares [][]byte
+	var xs []byte
+	var err error
+	switch b.field {
+	case fieldGF256:
+		if shares, err = shamir.SplitWithReader(b.randomReader, secret, b.parts, b.threshold); err != nil {
+			return nil, fmt.Errorf("failed to split secret: %w", err)
+		}
+		xs = make([]byte, len(shares))
+		for index := range shares {
+			xs[index] = shares[index][len(shares[index])-1]
+		}
+	case fieldGF65536:
+		// The coordinates are derived from the indexes, no need to track them.
+		if shares, err = shamir16.Split(b.randomReader, secret, b.parts, b.threshold); err != nil {
+			return nil, fmt.Errorf("failed to split secret: %w", err)
+		}
+	default:
+		return nil, errInvalidField
+	}
+	id, err := newSetID(b.randomReader)
 	if err != nil {
-		return nil, fmt.Errorf("failed to split secret: %w", err)
+		return nil, fmt.Errorf("failed to generate set id: %w", err)
 	}
+	set := &shareSet{id: id, parts: b.parts, threshold: b.threshold, field: b.field, xs: xs}
 	blocks := make([]*pem.Block, len(shares))
 	for index := range shares {
-		blocks[index] = &pem.Block{
-			Type: "SHAMIR",
-			Headers: map[string]string{
-				"N": strconv.Itoa(b.parts), "M": strconv.Itoa(b.threshold), "I": strconv.Itoa(index),
-			},
-			Bytes: shares[index],
-		}
+		blocks[index] = newBlock(set, index, shares[index])
+	}
+	return blocks, nil
+}
+
+func (b *splitBackend) splitPolicy(secret []byte) ([]*pem.Block, error) {
+	if b.encrypt || b.field != fieldGF256 {
+		return nil, errUnsupportedPolicy
+	}
+	root, err := parsePolicy(b.policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse policy: %w", err)
+	}
+	if root.isLeaf() {
+		return nil, fmt.Errorf("failed to find any gate: %w", errInvalidPolicy)
+	}
+	blocks, err := compilePolicy(b.randomReader, root, secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile policy: %w", err)
 	}
 	return blocks, nil
 }
 
-type combineBackend struct{}
+type combineBackend struct {
+	robust      bool
+	interactive bool
+}
 
 func combineBackendDefault() *combineBackend {
-	return &combineBackend{}
+	return &combineBackend{
+		robust:      robustDefault,
+		interactive: interactDefault,
+	}
 }
 
 func NewCmdCombine() *cobra.Command {
 	backend := combineBackendDefault()
-	cmd := &cobra.Command{Use: "combine", Args: cobra.NoArgs, RunE: backend.runE}
+	cmd := &cobra.Command{Use: "combine [file or directory]...", Args: cobra.ArbitraryArgs, RunE: backend.runE}
+	cmd.Flags().BoolVarP(&backend.robust, "robust", "r", robustDefault, fmt.Sprintf(
+		"use redundant shares to detect and skip corrupted ones (default %t)", robustDefault))
+	cmd.Flags().BoolVarP(&backend.interactive, "interactive", "i", interactDefault, fmt.Sprintf(
+		"type or paste shares one at a time on the terminal (default %t)", interactDefault))
 	return cmd
 }
 
-func (b *combineBackend) runE(_ *cobra.Command, _ []string) error {
-	data, err := io.ReadAll(os.Stdin)
+func (b *combineBackend) runE(_ *cobra.Command, args []string) error {
+	blocks, err := b.readBlocks(args)
 	if err != nil {
 		return fmt.Errorf("failed to read shares: %w", err)
 	}
-	var blocks []*pem.Block
-	for block, rest := pem.Decode(data); block != nil; {
-		blocks = append(blocks, block)
-		block, rest = pem.Decode(rest)
+	if blocks, err = openBlocks(blocks); err != nil {
+		return fmt.Errorf("failed to decrypt shares: %w", err)
 	}
 	secret, err := b.combine(blocks)
 	if err != nil {
@@ -117,14 +316,304 @@ func (b *combineBackend) runE(_ *cobra.Command, _ []string) error {
 	return nil
 }
 
+func (b *combineBackend) readBlocks(args []string) (blocks []*pem.Block, err error) {
+	switch {
+	case b.interactive && len(args) != 0:
+		return nil, errInteractiveFiles
+	case b.interactive:
+		terminal, err := dpass.OpenTerminal()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open terminal: %w", err)
+		}
+		defer func() {
+			if e := terminal.Close(); e != nil && err == nil {
+				err = fmt.Errorf("failed to close terminal: %w", e)
+			}
+		}()
+		return collectBlocks(terminal, os.Stderr, openShare)
+	case len(args) != 0:
+		return readBlockFiles(args)
+	default:
+		return readBlocks(os.Stdin)
+	}
+}
+
 func (b *combineBackend) combine(blocks []*pem.Block) ([]byte, error) {
+	if len(blocks) != 0 && blocks[0].Type == PolicyBlockType {
+		secret, holders, err := combinePolicy(blocks)
+		if err != nil {
+			return nil, fmt.Errorf("failed to combine policy: %w", err)
+		}
+		_, _ = fmt.Fprintf(os.Stderr, "policy satisfied by %s\n", strings.Join(holders, ", "))
+		return secret, nil
+	}
+	checked := make([]*pem.Block, 0, len(blocks))
+	for _, block := range blocks {
+		if err := checkBlock(block); err != nil {
+			if !b.robust {
+				return nil, fmt.Errorf("failed to check share %s: %w", block.Headers[HeaderIndex], err)
+			}
+			_, _ = fmt.Fprintf(os.Stderr, "share %s has a bad checksum and has been skipped\n",
+				block.Headers[HeaderIndex])
+			continue
+		}
+		checked = append(checked, block)
+	}
+	blocks = checked
 	shares := make([][]byte, 0, len(blocks))
 	for _, block := range blocks {
 		shares = append(shares, block.Bytes)
 	}
-	secret, err := shamir.Combine(shares)
+	field, err := parseField(blocks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse field: %w", err)
+	}
+	if b.robust {
+		if field != fieldGF256 {
+			return nil, errUnsupportedField
+		}
+		return b.combineRobust(blocks, shares)
+	}
+	var secret []byte
+	if field == fieldGF65536 {
+		secret, err = shamir16.Combine(shares)
+	} else {
+		secret, err = shamir.Combine(shares)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to combine shares: %w", err)
 	}
 	return secret, nil
 }
+
+func (b *combineBackend) combineRobust(blocks []*pem.Block, shares [][]byte) ([]byte, error) {
+	set, err := parseSet(blocks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse set: %w", err)
+	}
+	secret, bad, err := shamir.CombineRobust(shares, set.threshold)
+	if err != nil {
+		return nil, fmt.Errorf("failed to combine shares robustly: %w", err)
+	}
+	for _, position := range bad {
+		_, _ = fmt.Fprintf(os.Stderr, "share %s is inconsistent and has been skipped\n",
+			blocks[position].Headers[HeaderIndex])
+	}
+	return secret, nil
+}
+
+type reshardBackend struct {
+	combineBackend *combineBackend
+	splitBackend   *splitBackend
+}
+
+func reshardBackendDefault() *reshardBackend {
+	return &reshardBackend{
+		combineBackend: combineBackendDefault(),
+		splitBackend:   splitBackendDefault(),
+	}
+}
+
+func NewCmdReshard() *cobra.Command {
+	backend := reshardBackendDefault()
+	cmd := &cobra.Command{Use: "reshard", Args: cobra.NoArgs, RunE: backend.runE}
+	cmd.Flags().StringVarP(&backend.splitBackend.output, "output", "o", outputDefault,
+		"prefix of output files, use standard output if empty")
+	cmd.Flags().IntVarP(&backend.splitBackend.parts, "parts", "n", partsDefault,
+		"total number of new shares to be split into")
+	cmd.Flags().IntVarP(&backend.splitBackend.threshold, "threshold", "m", thresholdDefault,
+		"minimum number of new shares to reconstruct")
+	cmd.Flags().StringVar(&backend.splitBackend.field, "field", fieldDefault, fmt.Sprintf(
+		"finite field of new shares (%q up to 255 shares | %q up to %v shares)",
+		fieldGF256, fieldGF65536, shamir16.MaxParts))
+	cmd.Flags().BoolVarP(&backend.combineBackend.robust, "robust", "r", robustDefault, fmt.Sprintf(
+		"use redundant old shares to detect and skip corrupted ones (default %t)", robustDefault))
+	cmd.Flags().StringVar(&backend.splitBackend.manifest, "manifest", manifestDefault,
+		"path of manifest file of new shares, use \"<output>-manifest.json\" if empty and output is given")
+	cmd.Flags().StringSliceVar(&backend.splitBackend.holders, "holders", nil,
+		"names of new share holders in order of index, recorded in the manifest")
+	cmd.Flags().StringSliceVar(&backend.splitBackend.locations, "locations", nil,
+		"locations of new shares in order of index, recorded in the manifest")
+	return cmd
+}
+
+func (b *reshardBackend) runE(_ *cobra.Command, _ []string) error {
+	blocks, err := readBlocks(os.Stdin)
+	if err != nil {
+		return fmt.Errorf("failed to read shares: %w", err)
+	}
+	if blocks, err = openBlocks(blocks); err != nil {
+		return fmt.Errorf("failed to decrypt shares: %w", err)
+	}
+	resharded, m, err := b.reshard(blocks)
+	if err != nil {
+		return fmt.Errorf("failed to reshard: %w", err)
+	}
+	if err := writeBlocks(b.splitBackend.output, resharded); err != nil {
+		return err
+	}
+	if m == nil {
+		return nil
+	}
+	return writeManifest(b.splitBackend.manifestPath(), m)
+}
+
+// reshard recovers the secret from the old shares and splits it into a new
+// set of shares, the secret only lives in memory and is wiped afterwards. The
+// manifest of the new set is only created when it is going to be written.
+func (b *reshardBackend) reshard(blocks []*pem.Block) ([]*pem.Block, *manifest, error) {
+	if _, err := parseSet(blocks); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse set: %w", err)
+	}
+	secret, err := b.combineBackend.combine(blocks)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to combine: %w", err)
+	}
+	defer clear(secret)
+	resharded, err := b.splitBackend.split(secret)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to split: %w", err)
+	}
+	if len(b.splitBackend.manifestPath()) == 0 {
+		return resharded, nil, nil
+	}
+	sb := b.splitBackend
+	m, err := newManifest(secret, resharded, sb.now(), sb.randomReader, sb.holders, sb.locations)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create manifest: %w", err)
+	}
+	return resharded, m, nil
+}
+
+type extendBackend struct {
+	output    string
+	count     int
+	manifest  string
+	holders   []string
+	locations []string
+}
+
+func extendBackendDefault() *extendBackend {
+	return &extendBackend{
+		output:   outputDefault,
+		count:    countDefault,
+		manifest: manifestDefault,
+	}
+}
+
+func NewCmdExtend() *cobra.Command {
+	backend := extendBackendDefault()
+	cmd := &cobra.Command{Use: "share-extend", Args: cobra.NoArgs, RunE: backend.runE}
+	cmd.Flags().StringVarP(&backend.output, "output", "o", outputDefault,
+		"prefix of output files, use standard output if empty")
+	cmd.Flags().IntVarP(&backend.count, "count", "c", countDefault,
+		"number of additional shares to be issued")
+	cmd.Flags().StringVar(&backend.manifest, "manifest", manifestDefault,
+		"path of manifest file of the set to record the additional shares in, use \"<output>-manifest.json\" "+
+			"if empty and output is given, it is required so that no coordinate is issued twice")
+	cmd.Flags().StringSliceVar(&backend.holders, "holders", nil,
+		"names of additional share holders in order of index, recorded in the manifest")
+	cmd.Flags().StringSliceVar(&backend.locations, "locations", nil,
+		"locations of additional shares in order of index, recorded in the manifest")
+	return cmd
+}
+
+func (b *extendBackend) runE(_ *cobra.Command, _ []string) error {
+	blocks, err := readBlocks(os.Stdin)
+	if err != nil {
+		return fmt.Errorf("failed to read shares: %w", err)
+	}
+	if blocks, err = openBlocks(blocks); err != nil {
+		return fmt.Errorf("failed to decrypt shares: %w", err)
+	}
+	return b.run(blocks)
+}
+
+// run issues the additional shares and records them in the manifest. The
+// manifest is the only record of the shares issued so far, so it is required
+// and updated in memory first, shares that are not the latest ones are then
+// rejected as stale before any share is written, instead of issuing again a
+// coordinate that is already held.
+func (b *extendBackend) run(blocks []*pem.Block) error {
+	path := b.manifest
+	if len(path) == 0 && len(b.output) != 0 {
+		path = b.output + "-manifest.json"
+	}
+	if len(path) == 0 {
+		return errMissingManifest
+	}
+	m, err := readManifest(path)
+	if err != nil {
+		return fmt.Errorf("failed to read manifest: %w", err)
+	}
+	extended, err := b.extend(blocks)
+	if err != nil {
+		return fmt.Errorf("failed to extend: %w", err)
+	}
+	if err := m.extend(extended, b.holders, b.locations); err != nil {
+		return fmt.Errorf("failed to update manifest: %w", err)
+	}
+	if err := writeBlocks(b.output, extended); err != nil {
+		return err
+	}
+	return writeManifest(path, m)
+}
+
+func (b *extendBackend) extend(blocks []*pem.Block) ([]*pem.Block, error) {
+	if b.count < 1 {
+		return nil, errInvalidCount
+	}
+	set, err := parseSet(blocks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse set: %w", err)
+	}
+	shares := make([][]byte, 0, len(blocks))
+	for _, block := range blocks {
+		shares = append(shares, block.Bytes)
+	}
+	if set.field == fieldGF65536 {
+		return b.extend16(set, shares)
+	}
+	if set.xs == nil {
+		return nil, errMissingCoordinate
+	}
+	xs := slices.Clone(set.xs)
+	extended := make([][]byte, 0, b.count)
+	for x := 1; x <= 255 && len(extended) < b.count; x++ {
+		if slices.Contains(xs, byte(x)) {
+			continue
+		}
+		share, err := shamir.Evaluate(shares, byte(x))
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate share: %w", err)
+		}
+		xs = append(xs, byte(x))
+		extended = append(extended, share)
+	}
+	if len(extended) < b.count {
+		return nil, errNoCoordinateLeft
+	}
+	next := &shareSet{id: set.id, parts: len(xs), threshold: set.threshold, field: set.field, xs: xs}
+	result := make([]*pem.Block, len(extended))
+	for i := range extended {
+		result[i] = newBlock(next, set.parts+i, extended[i])
+	}
+	return result, nil
+}
+
+func (b *extendBackend) extend16(set *shareSet, shares [][]byte) ([]*pem.Block, error) {
+	next := &shareSet{id: set.id, parts: set.parts + b.count, threshold: set.threshold, field: set.field}
+	if next.parts > shamir16.MaxParts {
+		return nil, errNoCoordinateLeft
+	}
+	result := make([]*pem.Block, b.count)
+	for i := range result {
+		index := set.parts + i
+		share, err := shamir16.Evaluate(shares, uint16(index+1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate share: %w", err)
+		}
+		result[i] = newBlock(next, index, share)
+	}
+	return result, nil
+}
@@ -3,6 +3,10 @@ package shamir
 import (
 	"bytes"
 	"encoding/pem"
+	"errors"
+	"maps"
+	"path/filepath"
+	"slices"
 	"testing"
 )
 
@@ -27,6 +31,81 @@ func TestSplitBackend(t *testing.T) {
 	}
 }
 
+func TestSplitBackendReproducible(t *testing.T) {
+	secret := []byte("To be, or not to be, that is the question.")
+	sb := splitBackendDefault()
+	sb.parts = 5
+	sb.threshold = 3
+	sb.randomReader = bytes.NewReader(bytes.Repeat([]byte("ccc66c168049"), 16))
+	blocks, err := sb.split(secret)
+	if err != nil {
+		t.Fatalf("failed to split: %v", err)
+	}
+	var output []byte
+	for _, block := range blocks {
+		output = append(output, pem.EncodeToMemory(block)...)
+	}
+	want := `-----BEGIN SHAMIR-----
+C: b021566f
+F: gf256
+I: 0
+M: 3
+N: 5
+S: 6331363830343963
+X: 100,101,102,58,59
+
+CZoFswP/fZpX8Qi8KdVRvkaxONkFpQ6yKdVMokanNZAFoBO2LoFMvgj9ZA==
+-----END SHAMIR-----
+-----BEGIN SHAMIR-----
+C: 72d95deb
+F: gf256
+I: 1
+M: 3
+N: 5
+S: 6331363830343963
+X: 100,101,102,58,59
+
+W5QB6QP/L5RTqwi8e9tV5EaxatcB/w6ye9tI+EanZ54B+hO2fI9I5Aj9ZQ==
+-----END SHAMIR-----
+-----BEGIN SHAMIR-----
+C: 65af85ee
+F: gf256
+I: 2
+M: 3
+N: 5
+S: 6331363830343963
+X: 100,101,102,58,59
+
+Cxa1VlJLfxbnFFkIK1nhWxcFOlW1QF8GK1n8RxcTNxy1RUICLA38W1lJZg==
+-----END SHAMIR-----
+-----BEGIN SHAMIR-----
+C: d7d69fa0
+F: gf256
+I: 3
+M: 3
+N: 5
+S: 6331363830343963
+X: 100,101,102,58,59
+
+DdtHBDOxedsVRjjyLZQTCXb/PJhHEj78LZQOFXbpMdFHFyP4KsAOCTizOg==
+-----END SHAMIR-----
+-----BEGIN SHAMIR-----
+C: af16636b
+F: gf256
+I: 4
+M: 3
+N: 5
+S: 6331363830343963
+X: 100,101,102,58,59
+
+X9VDXjOxK9URHDjyf5oXU3b/bpZDSD78f5oKT3bpY99DTSP4eM4KUzizOw==
+-----END SHAMIR-----
+`
+	if string(output) != want {
+		t.Errorf("got = %s, want = %s", output, want)
+	}
+}
+
 func TestCombineBackend(t *testing.T) {
 	secret := []byte("To be, or not to be, that is the question.")
 	blocks := make([]*pem.Block, 9)
@@ -123,6 +202,155 @@ N: 9
 	}
 }
 
+func TestCombineBackendRobust(t *testing.T) {
+	secret := []byte("To be, or not to be, that is the question.")
+	sb := splitBackendDefault()
+	sb.parts = 9
+	sb.threshold = 4
+	blocks, err := sb.split(secret)
+	if err != nil {
+		t.Fatalf("failed to split: %v", err)
+	}
+	corrupted := *blocks[3]
+	corrupted.Headers = maps.Clone(blocks[3].Headers)
+	corrupted.Bytes = bytes.Clone(blocks[3].Bytes)
+	corrupted.Bytes[7] ^= 0x20
+	corrupted.Headers[HeaderChecksum] = checksum(corrupted.Bytes)
+	blocks[3] = &corrupted
+	mistyped := *blocks[5]
+	mistyped.Bytes = bytes.Clone(blocks[5].Bytes)
+	mistyped.Bytes[0] ^= 0x01
+	cb := combineBackendDefault()
+	if _, err := cb.combine(append(slices.Clone(blocks), &mistyped)); err == nil {
+		t.Fatalf("expect error with bad checksum")
+	}
+	combinedSecret, err := cb.combine(blocks)
+	if err != nil {
+		t.Fatalf("failed to combine: %v", err)
+	}
+	if bytes.Equal(combinedSecret, secret) {
+		t.Fatalf("expect corrupted secret without robust mode")
+	}
+	cb.robust = true
+	blocks[5] = &mistyped
+	combinedSecret, err = cb.combine(blocks)
+	if err != nil {
+		t.Fatalf("failed to combine: %v", err)
+	}
+	if !bytes.Equal(combinedSecret, secret) {
+		t.Fatalf("got = %v, want = %v", combinedSecret, secret)
+	}
+}
+
+func TestReshardBackend(t *testing.T) {
+	secret := []byte("To be, or not to be, that is the question.")
+	sb := splitBackendDefault()
+	sb.parts = 5
+	sb.threshold = 3
+	blocks, err := sb.split(secret)
+	if err != nil {
+		t.Fatalf("failed to split: %v", err)
+	}
+	rb := reshardBackendDefault()
+	rb.splitBackend.parts = 9
+	rb.splitBackend.threshold = 4
+	if _, _, err := rb.reshard(blocks[:2]); err == nil {
+		t.Fatalf("expect error when less than threshold shares are supplied")
+	}
+	resharded, m, err := rb.reshard(blocks[1:4])
+	if err != nil {
+		t.Fatalf("failed to reshard: %v", err)
+	}
+	if len(resharded) != 9 || resharded[0].Headers[HeaderThreshold] != "4" || m != nil {
+		t.Fatalf("got = %v, want 9 shares with threshold 4", resharded)
+	}
+	for _, group := range groups94(resharded) {
+		cb := combineBackendDefault()
+		combinedSecret, err := cb.combine(group)
+		if err != nil {
+			t.Fatalf("failed to combine: %v", err)
+		}
+		if !bytes.Equal(combinedSecret, secret) {
+			t.Fatalf("got = %v, want = %v", combinedSecret, secret)
+		}
+	}
+}
+
+func TestExtendBackend(t *testing.T) {
+	secret := []byte("To be, or not to be, that is the question.")
+	sb := splitBackendDefault()
+	sb.parts = 8
+	sb.threshold = 4
+	blocks, err := sb.split(secret)
+	if err != nil {
+		t.Fatalf("failed to split: %v", err)
+	}
+	eb := extendBackendDefault()
+	extended, err := eb.extend(blocks[2:6])
+	if err != nil {
+		t.Fatalf("failed to extend: %v", err)
+	}
+	if len(extended) != 1 || extended[0].Headers[HeaderIndex] != "8" || extended[0].Headers[HeaderParts] != "9" {
+		t.Fatalf("got = %v, want one share with index 8 of 9", extended)
+	}
+	if _, err := eb.extend(blocks[:3]); err == nil {
+		t.Fatalf("expect error when less than threshold shares are supplied")
+	}
+	for _, group := range groups94(append(blocks, extended[0])) {
+		cb := combineBackendDefault()
+		combinedSecret, err := cb.combine(group)
+		if err != nil {
+			t.Fatalf("failed to combine: %v", err)
+		}
+		if !bytes.Equal(combinedSecret, secret) {
+			t.Fatalf("got = %v, want = %v", combinedSecret, secret)
+		}
+	}
+	if _, err := eb.extend(append(blocks[:3], extended[0])); err != nil {
+		t.Fatalf("failed to extend with mixed shares: %v", err)
+	}
+}
+
+func TestSplitBackendGF65536(t *testing.T) {
+	secret := []byte("To be, or not to be, that is the question.")
+	sb := splitBackendDefault()
+	sb.parts = 300
+	sb.threshold = 4
+	sb.field = fieldGF65536
+	blocks, err := sb.split(secret)
+	if err != nil {
+		t.Fatalf("failed to split: %v", err)
+	}
+	if blocks[299].Headers[HeaderField] != fieldGF65536 || blocks[299].Headers[HeaderXs] != "" {
+		t.Fatalf("got = %v, want headers of %v", blocks[299].Headers, fieldGF65536)
+	}
+	eb := extendBackendDefault()
+	extended, err := eb.extend(blocks[100:104])
+	if err != nil {
+		t.Fatalf("failed to extend: %v", err)
+	}
+	if extended[0].Headers[HeaderIndex] != "300" || extended[0].Headers[HeaderParts] != "301" {
+		t.Fatalf("got = %v, want share with index 300 of 301", extended[0].Headers)
+	}
+	for _, group := range groups94([]*pem.Block{
+		blocks[0], blocks[42], blocks[255], blocks[256], blocks[299],
+		blocks[7], blocks[8], blocks[9], extended[0],
+	}) {
+		cb := combineBackendDefault()
+		combinedSecret, err := cb.combine(group)
+		if err != nil {
+			t.Fatalf("failed to combine: %v", err)
+		}
+		if !bytes.Equal(combinedSecret, secret) {
+			t.Fatalf("got = %v, want = %v", combinedSecret, secret)
+		}
+	}
+	sb.field = fieldGF256
+	if _, err := sb.split(secret); err == nil {
+		t.Fatalf("expect error with more than 255 parts over %v", fieldGF256)
+	}
+}
+
 func groups94(blocks []*pem.Block) [][]*pem.Block {
 	var groups [][]*pem.Block
 	for a := 0; a < 9; a++ {
@@ -138,3 +366,40 @@ func groups94(blocks []*pem.Block) [][]*pem.Block {
 	}
 	return groups
 }
+
+func TestExtendBackendRun(t *testing.T) {
+	secret := []byte("To be, or not to be, that is the question.")
+	dir := t.TempDir()
+	sb := splitBackendDefault()
+	sb.parts, sb.threshold = 5, 3
+	sb.output = filepath.Join(dir, "share")
+	if err := sb.run(secret); err != nil {
+		t.Fatalf("failed to split: %v", err)
+	}
+	blocks, err := readBlockFiles([]string{dir})
+	if err != nil {
+		t.Fatalf("failed to read shares: %v", err)
+	}
+	eb := extendBackendDefault()
+	eb.output = sb.output
+	if err := eb.run(blocks[:3]); err != nil {
+		t.Fatalf("failed to extend: %v", err)
+	}
+	// Extending the original shares again would issue the same coordinate.
+	if err := eb.run(blocks[:3]); !errors.Is(err, errStaleManifest) {
+		t.Fatalf("got = %v, want = %v", err, errStaleManifest)
+	}
+	extended, err := readBlockFiles([]string{dir})
+	if err != nil {
+		t.Fatalf("failed to read shares: %v", err)
+	}
+	if len(extended) != 6 {
+		t.Fatalf("got = %v shares, want = 6", len(extended))
+	}
+	if err := eb.run(extended[3:6]); err != nil {
+		t.Fatalf("failed to extend latest shares: %v", err)
+	}
+	if err := extendBackendDefault().run(blocks[:3]); !errors.Is(err, errMissingManifest) {
+		t.Fatalf("got = %v, want = %v", err, errMissingManifest)
+	}
+}
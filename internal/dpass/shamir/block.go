@@ -0,0 +1,340 @@
+package shamir
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/rbee3u/dpass/pkg/hashx"
+	"github.com/rbee3u/dpass/pkg/shamir16"
+)
+
+type shareSet struct {
+	id        string
+	parts     int
+	threshold int
+	field     string
+	xs        []byte
+}
+
+// parseSet checks that the blocks belong to the same set of shares, and that
+// there are enough of them to reconstruct the underlying polynomials.
+func parseSet(blocks []*pem.Block) (*shareSet, error) {
+	set, err := checkSet(blocks)
+	if err != nil {
+		return nil, err
+	}
+	if len(blocks) < set.threshold {
+		return nil, errNotEnoughShares
+	}
+	return set, nil
+}
+
+// checkSet checks that the blocks belong to the same set of shares.
+func checkSet(blocks []*pem.Block) (*shareSet, error) {
+	var set *shareSet
+	seen := make(map[int]bool, len(blocks))
+	for _, block := range blocks {
+		if block.Type != BlockType {
+			return nil, errInvalidBlockType
+		}
+		if err := checkBlock(block); err != nil {
+			return nil, err
+		}
+		id := block.Headers[HeaderSet]
+		parts, err := strconv.Atoi(block.Headers[HeaderParts])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse parts: %w", errInvalidHeader)
+		}
+		threshold, err := strconv.Atoi(block.Headers[HeaderThreshold])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse threshold: %w", errInvalidHeader)
+		}
+		index, err := strconv.Atoi(block.Headers[HeaderIndex])
+		if err != nil || index < 0 || index >= parts {
+			return nil, fmt.Errorf("failed to parse index: %w", errInvalidHeader)
+		}
+		if seen[index] {
+			return nil, errDuplicateShare
+		}
+		seen[index] = true
+		field, err := parseField([]*pem.Block{block})
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse field: %w", err)
+		}
+		// The share of a sealed block is encrypted, its coordinate is only
+		// known once it has been opened.
+		_, sealed := block.Headers[HeaderEnvelope]
+		if !sealed && field == fieldGF65536 && (len(block.Bytes) < shamir16.ShareOverhead ||
+			int(shamir16.Coordinate(block.Bytes)) != index+1) {
+			return nil, fmt.Errorf("failed to match coordinate: %w", errInvalidHeader)
+		}
+		xs, err := parseXs(block.Headers[HeaderXs])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse coordinates: %w", err)
+		}
+		if xs != nil && len(xs) != parts {
+			return nil, fmt.Errorf("failed to match coordinate: %w", errInvalidHeader)
+		}
+		if !sealed && xs != nil && (len(block.Bytes) == 0 || xs[index] != block.Bytes[len(block.Bytes)-1]) {
+			return nil, fmt.Errorf("failed to match coordinate: %w", errInvalidHeader)
+		}
+		if set == nil {
+			set = &shareSet{id: id, parts: parts, threshold: threshold, field: field, xs: xs}
+			continue
+		}
+		if set.id != id || set.threshold != threshold || set.field != field || (set.xs == nil) != (xs == nil) {
+			return nil, errInconsistentSet
+		}
+		// Over GF(2^16) the coordinates follow the indexes, shares issued later
+		// by share-extend simply know about more parts.
+		if field == fieldGF65536 {
+			set.parts = max(set.parts, parts)
+			continue
+		}
+		// Shares issued later by share-extend know about more coordinates,
+		// so the coordinates of the older shares must be a prefix of them.
+		if xs == nil && set.parts != parts {
+			return nil, errInconsistentSet
+		}
+		if len(xs) > len(set.xs) {
+			set.parts, set.xs, xs = parts, xs, set.xs
+		}
+		if !slices.Equal(set.xs[:len(xs)], xs) {
+			return nil, errInconsistentSet
+		}
+	}
+	if set == nil {
+		return nil, errNotEnoughShares
+	}
+	return set, nil
+}
+
+// parseField returns the field shared by all the blocks, shares made before
+// the field was recorded are over GF(2^8).
+func parseField(blocks []*pem.Block) (string, error) {
+	field := ""
+	for _, block := range blocks {
+		value, exist := block.Headers[HeaderField]
+		if !exist {
+			value = fieldGF256
+		}
+		if value != fieldGF256 && value != fieldGF65536 {
+			return "", errInvalidField
+		}
+		if len(field) != 0 && field != value {
+			return "", errInconsistentSet
+		}
+		field = value
+	}
+	return field, nil
+}
+
+func parseXs(value string) ([]byte, error) {
+	if len(value) == 0 {
+		return nil, nil
+	}
+	fields := strings.Split(value, ",")
+	xs := make([]byte, len(fields))
+	for i, field := range fields {
+		x, err := strconv.ParseUint(field, 10, 8)
+		if err != nil || x == 0 || slices.Contains(xs[:i], byte(x)) {
+			return nil, errInvalidHeader
+		}
+		xs[i] = byte(x)
+	}
+	return xs, nil
+}
+
+func formatXs(xs []byte) string {
+	fields := make([]string, len(xs))
+	for i, x := range xs {
+		fields[i] = strconv.Itoa(int(x))
+	}
+	return strings.Join(fields, ",")
+}
+
+func newBlock(set *shareSet, index int, share []byte) *pem.Block {
+	block := &pem.Block{
+		Type: BlockType,
+		Headers: map[string]string{
+			HeaderSet:       set.id,
+			HeaderParts:     strconv.Itoa(set.parts),
+			HeaderThreshold: strconv.Itoa(set.threshold),
+			HeaderIndex:     strconv.Itoa(index),
+			HeaderField:     set.field,
+			HeaderChecksum:  checksum(share),
+		},
+		Bytes: share,
+	}
+	if set.xs != nil {
+		block.Headers[HeaderXs] = formatXs(set.xs)
+	}
+	return block
+}
+
+// newSetID returns a random identifier shared by all shares of the same split.
+func newSetID(random io.Reader) (string, error) {
+	id := make([]byte, setIDSize)
+	if _, err := io.ReadFull(random, id); err != nil {
+		return "", fmt.Errorf("failed to read set id: %w", err)
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// checksum catches typos of shares copied by hand, it is not a MAC.
+func checksum(data []byte) string {
+	return hex.EncodeToString(hashx.Sha256Sum(data)[:checksumSize])
+}
+
+// checkBlock verifies the checksum of a block, if any.
+func checkBlock(block *pem.Block) error {
+	if value, exist := block.Headers[HeaderChecksum]; exist && value != checksum(block.Bytes) {
+		return errChecksumMismatch
+	}
+	return nil
+}
+
+func readBlocks(r io.Reader) ([]*pem.Block, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data: %w", err)
+	}
+	return decodeShares(data)
+}
+
+func decodeBlocks(data []byte) []*pem.Block {
+	var blocks []*pem.Block
+	for block, rest := pem.Decode(data); block != nil; {
+		blocks = append(blocks, block)
+		block, rest = pem.Decode(rest)
+	}
+	return blocks
+}
+
+// readBlockFiles reads the blocks from the files, the directories are walked
+// through in lexical order.
+func readBlockFiles(paths []string) ([]*pem.Block, error) {
+	var blocks []*pem.Block
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil || entry.IsDir() {
+				return err
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to read file: %w", err)
+			}
+			decoded, err := decodeShares(data)
+			if err != nil {
+				return fmt.Errorf("failed to decode %s: %w", path, err)
+			}
+			blocks = append(blocks, decoded...)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to walk %s: %w", root, err)
+		}
+	}
+	return blocks, nil
+}
+
+// collectBlocks asks the operator to paste the shares one by one, each share
+// is checked before asking for the next one until the threshold is reached.
+// A sealed share is opened as soon as it is pasted, so that its coordinate is
+// checked and a wrong password only costs that share.
+func collectBlocks(r io.Reader, w io.Writer, open func(*pem.Block) (*pem.Block, error)) ([]*pem.Block, error) {
+	scanner := bufio.NewScanner(r)
+	var blocks []*pem.Block
+	var set *shareSet
+	for set == nil || len(blocks) < set.threshold {
+		if set == nil {
+			_, _ = fmt.Fprintln(w, "Paste share 1, finish with its END line or a blank line after words:")
+		} else {
+			_, _ = fmt.Fprintf(w, "Paste share %v of %v, finish with its END line or a blank line after words:\n", len(blocks)+1, set.threshold)
+		}
+		data, isWords, err := scanShare(scanner)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan share: %w", err)
+		}
+		if len(data) == 0 {
+			return nil, errNotEnoughShares
+		}
+		var block *pem.Block
+		if isWords {
+			if block, err = decodeWords(lineWords(string(data))); err != nil {
+				_, _ = fmt.Fprintf(w, "Rejected share: %v, please try again.\n", err)
+				continue
+			}
+		} else if block, _ = pem.Decode(data); block == nil {
+			_, _ = fmt.Fprintln(w, "Invalid share, please try again.")
+			continue
+		}
+		if _, sealed := block.Headers[HeaderEnvelope]; sealed {
+			if block, err = open(block); err != nil {
+				_, _ = fmt.Fprintf(w, "Rejected share: %v, please try again.\n", err)
+				continue
+			}
+		}
+		next, err := checkSet(append(slices.Clone(blocks), block))
+		if err != nil {
+			_, _ = fmt.Fprintf(w, "Rejected share: %v, please try again.\n", err)
+			continue
+		}
+		blocks, set = append(blocks, block), next
+		_, _ = fmt.Fprintf(w, "%v of %v collected\n", len(blocks), set.threshold)
+	}
+	return blocks, nil
+}
+
+// scanShare reads a PEM share up to its END line, or a word share up to the
+// first blank line, any line of dashes marks the input as PEM.
+func scanShare(scanner *bufio.Scanner) ([]byte, bool, error) {
+	var data []byte
+	isWords := false
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(data) == 0 {
+			if len(line) == 0 {
+				continue
+			}
+			isWords = true
+		}
+		if strings.HasPrefix(line, "-----") {
+			isWords = false
+		}
+		if isWords && len(line) == 0 {
+			break
+		}
+		data = append(data, line+"\n"...)
+		if strings.HasPrefix(line, "-----END ") {
+			break
+		}
+	}
+	return data, isWords, scanner.Err()
+}
+
+func writeBlocks(output string, blocks []*pem.Block) error {
+	for _, block := range blocks {
+		var err error
+		if len(output) == 0 {
+			err = pem.Encode(os.Stdout, block)
+		} else {
+			path := fmt.Sprintf("%s-%v-%v-%v.txt", output,
+				block.Headers[HeaderParts], block.Headers[HeaderThreshold], block.Headers[HeaderIndex])
+			err = os.WriteFile(path, pem.EncodeToMemory(block), fileMode)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write block: %w", err)
+		}
+	}
+	return nil
+}
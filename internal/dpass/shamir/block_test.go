@@ -0,0 +1,143 @@
+package shamir
+
+import (
+	"bytes"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestReadBlockFiles(t *testing.T) {
+	secret := []byte("To be, or not to be, that is the question.")
+	sb := splitBackendDefault()
+	sb.parts = 5
+	sb.threshold = 3
+	blocks, err := sb.split(secret)
+	if err != nil {
+		t.Fatalf("failed to split: %v", err)
+	}
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "more"), 0o700); err != nil {
+		t.Fatalf("failed to mkdir: %v", err)
+	}
+	for i, name := range []string{"a.txt", "more/b.txt", "c.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), pem.EncodeToMemory(blocks[i]), fileMode); err != nil {
+			t.Fatalf("failed to write: %v", err)
+		}
+	}
+	read, err := readBlockFiles([]string{filepath.Join(dir, "more"), filepath.Join(dir, "a.txt"), filepath.Join(dir, "c.txt")})
+	if err != nil {
+		t.Fatalf("failed to read block files: %v", err)
+	}
+	combinedSecret, err := combineBackendDefault().combine(read)
+	if err != nil {
+		t.Fatalf("failed to combine: %v", err)
+	}
+	if !bytes.Equal(combinedSecret, secret) {
+		t.Fatalf("got = %v, want = %v", combinedSecret, secret)
+	}
+	if _, err := readBlockFiles([]string{filepath.Join(dir, "missing")}); err == nil {
+		t.Fatalf("expect error with missing file")
+	}
+}
+
+func TestCollectBlocks(t *testing.T) {
+	secret := []byte("To be, or not to be, that is the question.")
+	sb := splitBackendDefault()
+	sb.parts = 5
+	sb.threshold = 3
+	blocks, err := sb.split(secret)
+	if err != nil {
+		t.Fatalf("failed to split: %v", err)
+	}
+	others, err := sb.split(secret)
+	if err != nil {
+		t.Fatalf("failed to split: %v", err)
+	}
+	lines := strings.Split(string(pem.EncodeToMemory(blocks[2])), "\n")
+	body := []byte(lines[len(lines)-3])
+	body[0] = map[bool]byte{true: 'A', false: 'B'}[body[0] != 'A']
+	lines[len(lines)-3] = string(body)
+	mistyped := strings.Join(lines, "\n")
+	var input bytes.Buffer
+	input.Write(pem.EncodeToMemory(blocks[4]))
+	input.WriteString("garbage\n-----END SHAMIR-----\n")
+	input.Write(pem.EncodeToMemory(others[1]))
+	input.Write(pem.EncodeToMemory(blocks[4]))
+	input.WriteString(mistyped)
+	input.Write(pem.EncodeToMemory(blocks[2]))
+	input.Write(pem.EncodeToMemory(blocks[0]))
+	var output bytes.Buffer
+	collected, err := collectBlocks(&input, &output, nil)
+	if err != nil {
+		t.Fatalf("failed to collect blocks: %v", err)
+	}
+	if len(collected) != 3 || strings.Count(output.String(), "Rejected share") != 3 ||
+		strings.Count(output.String(), "Invalid share") != 1 {
+		t.Fatalf("got %v blocks with output:\n%s", len(collected), output.String())
+	}
+	for _, progress := range []string{"1 of 3 collected", "2 of 3 collected", "3 of 3 collected"} {
+		if !strings.Contains(output.String(), progress) {
+			t.Fatalf("expect %q in output:\n%s", progress, output.String())
+		}
+	}
+	combinedSecret, err := combineBackendDefault().combine(collected)
+	if err != nil {
+		t.Fatalf("failed to combine: %v", err)
+	}
+	if !bytes.Equal(combinedSecret, secret) {
+		t.Fatalf("got = %v, want = %v", combinedSecret, secret)
+	}
+	if _, err := collectBlocks(strings.NewReader(""), &output, nil); err == nil {
+		t.Fatalf("expect error without input")
+	}
+}
+
+func TestCollectBlocksSealed(t *testing.T) {
+	secret := []byte("To be, or not to be, that is the question.")
+	for _, field := range []string{fieldGF256, fieldGF65536} {
+		sb := splitBackendDefault()
+		sb.parts, sb.threshold, sb.field = 3, 2, field
+		blocks, err := sb.split(secret)
+		if err != nil {
+			t.Fatalf("failed to split: %v", err)
+		}
+		keys := [][]byte{[]byte("a7b2fa8897cf785e2e5dbca7648617d4"), []byte("b7b2fa8897cf785e2e5dbca7648617d4")}
+		var input bytes.Buffer
+		for index, key := range keys {
+			sealed, err := sealBlock(blocks[index], key, bytes.NewReader([]byte("ccc66c168049")))
+			if err != nil {
+				t.Fatalf("failed to seal: %v", err)
+			}
+			input.Write(pem.EncodeToMemory(sealed))
+			input.Write(pem.EncodeToMemory(sealed))
+		}
+		// Every share is pasted twice, the first time with a wrong password.
+		attempts := make(map[string]int)
+		open := func(block *pem.Block) (*pem.Block, error) {
+			index, _ := strconv.Atoi(block.Headers[HeaderIndex])
+			if attempts[block.Headers[HeaderIndex]]++; attempts[block.Headers[HeaderIndex]] == 1 {
+				return openBlock(block, []byte("c7b2fa8897cf785e2e5dbca7648617d4"))
+			}
+			return openBlock(block, keys[index])
+		}
+		var output bytes.Buffer
+		collected, err := collectBlocks(&input, &output, open)
+		if err != nil {
+			t.Fatalf("failed to collect blocks: %v\n%s", err, output.String())
+		}
+		if strings.Count(output.String(), "Rejected share") != 2 {
+			t.Fatalf("got output:\n%s", output.String())
+		}
+		combinedSecret, err := combineBackendDefault().combine(collected)
+		if err != nil {
+			t.Fatalf("failed to combine: %v", err)
+		}
+		if !bytes.Equal(combinedSecret, secret) {
+			t.Fatalf("got = %v, want = %v", combinedSecret, secret)
+		}
+	}
+}
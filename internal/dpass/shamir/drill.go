@@ -0,0 +1,183 @@
+package shamir
+
+import (
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/rbee3u/dpass/internal/dpass"
+	"github.com/rbee3u/dpass/internal/dpass/aes256"
+	"github.com/rbee3u/dpass/pkg/chains"
+	"github.com/spf13/cobra"
+)
+
+const (
+	fingerprintDefault = ""
+	saltDefault        = ""
+	decryptDefault     = false
+	chainDefault       = ""
+	addressDefault     = ""
+)
+
+var (
+	errDrillFailed    = errors.New("drill failed")
+	errInvalidChain   = errors.New("invalid chain")
+	errMissingAddress = errors.New("missing address")
+	errInvalidSalt    = errors.New("invalid fingerprint salt")
+)
+
+type drillBackend struct {
+	manifest    string
+	fingerprint string
+	salt        string
+	decrypt     bool
+	chain       string
+	address     string
+	robust      bool
+}
+
+func drillBackendDefault() *drillBackend {
+	return &drillBackend{
+		manifest:    manifestDefault,
+		fingerprint: fingerprintDefault,
+		salt:        saltDefault,
+		decrypt:     decryptDefault,
+		chain:       chainDefault,
+		address:     addressDefault,
+		robust:      robustDefault,
+	}
+}
+
+func NewCmdDrill() *cobra.Command {
+	backend := drillBackendDefault()
+	cmd := &cobra.Command{Use: "drill [file or directory]...", Args: cobra.ArbitraryArgs, RunE: backend.runE}
+	cmd.Flags().StringVar(&backend.manifest, "manifest", manifestDefault,
+		"manifest to check the shares and the fingerprint of the secret against")
+	cmd.Flags().StringVar(&backend.fingerprint, "fingerprint", fingerprintDefault,
+		"expected fingerprint of the secret, take it from the manifest if empty")
+	cmd.Flags().StringVar(&backend.salt, "fingerprint-salt", saltDefault,
+		"hex salt of the expected fingerprint, take it from the manifest if empty")
+	cmd.Flags().BoolVar(&backend.decrypt, "decrypt", decryptDefault, fmt.Sprintf(
+		"decrypt the secret in memory with a password (default %t)", decryptDefault))
+	cmd.Flags().StringVar(&backend.chain, "chain", chainDefault, fmt.Sprintf(
+		"dcoin chain to derive a watch-only address with (%s)", strings.Join(chains.Names(), " | ")))
+	cmd.Flags().StringVar(&backend.address, "address", addressDefault,
+		"expected address at the default path of the chain")
+	cmd.Flags().BoolVarP(&backend.robust, "robust", "r", robustDefault, fmt.Sprintf(
+		"use redundant shares to detect and skip corrupted ones (default %t)", robustDefault))
+	return cmd
+}
+
+func (b *drillBackend) runE(_ *cobra.Command, args []string) error {
+	if len(b.chain) != 0 && len(b.address) == 0 {
+		return errMissingAddress
+	}
+	if _, exist := chains.DeriveAddress[b.chain]; len(b.chain) != 0 && !exist {
+		return errInvalidChain
+	}
+	var m *manifest
+	if len(b.manifest) != 0 {
+		var err error
+		if m, err = readManifest(b.manifest); err != nil {
+			return fmt.Errorf("failed to read manifest: %w", err)
+		}
+	}
+	blocks, err := (&combineBackend{}).readBlocks(args)
+	if err != nil {
+		return fmt.Errorf("failed to read shares: %w", err)
+	}
+	if blocks, err = openBlocks(blocks); err != nil {
+		return fmt.Errorf("failed to decrypt shares: %w", err)
+	}
+	var key []byte
+	if b.decrypt {
+		password, err := dpass.ReadPassword("Password For Decrypt:")
+		if err != nil {
+			return fmt.Errorf("failed to read password: %w", err)
+		}
+		key = dpass.DeriveKey(password)
+	}
+	return b.drill(os.Stdout, m, blocks, key)
+}
+
+// drill reconstructs the secret in memory and reports whether it passes each
+// check, the secret itself is never written anywhere.
+func (b *drillBackend) drill(w io.Writer, m *manifest, blocks []*pem.Block, key []byte) error {
+	failed := false
+	report := func(name string, err error) {
+		if err != nil {
+			failed = true
+			_, _ = fmt.Fprintf(w, "%s: FAIL (%v)\n", name, err)
+		} else {
+			_, _ = fmt.Fprintf(w, "%s: PASS\n", name)
+		}
+	}
+	if m != nil {
+		report("manifest", manifestCheckBackendDefault().check(w, m, blocks))
+	}
+	secret, err := b.combine(blocks)
+	report("combine", err)
+	defer clear(secret)
+	if err == nil {
+		expected, salt := b.fingerprint, b.salt
+		if len(expected) == 0 && m != nil {
+			expected = m.Fingerprint
+		}
+		if len(salt) == 0 && m != nil {
+			salt = m.Salt
+		}
+		if len(expected) != 0 {
+			report("fingerprint", checkFingerprint(secret, salt, expected))
+		}
+		if key != nil {
+			var plaintext []byte
+			plaintext, err = aes256.Decrypt(key, secret)
+			report("decrypt", err)
+			defer clear(plaintext)
+			secret = plaintext
+		}
+	}
+	if err == nil && len(b.chain) != 0 {
+		address, err := chains.DeriveAddress[b.chain](strings.Join(strings.Fields(string(secret)), " "))
+		if err == nil {
+			err = compareResult(address, b.address)
+		}
+		report(b.chain+" address", err)
+	}
+	if failed {
+		_, _ = fmt.Fprintln(w, "drill FAILED")
+		return errDrillFailed
+	}
+	_, _ = fmt.Fprintln(w, "drill PASSED")
+	return nil
+}
+
+// combine refuses to reconstruct from fewer shares than the threshold, which
+// would otherwise silently yield a wrong secret.
+func (b *drillBackend) combine(blocks []*pem.Block) ([]byte, error) {
+	if len(blocks) == 0 || blocks[0].Type != PolicyBlockType {
+		if _, err := parseSet(blocks); err != nil {
+			return nil, fmt.Errorf("failed to parse set: %w", err)
+		}
+	}
+	return (&combineBackend{robust: b.robust}).combine(blocks)
+}
+
+func checkFingerprint(secret []byte, salt, expected string) error {
+	decoded, err := hex.DecodeString(salt)
+	if err != nil || len(decoded) == 0 {
+		return errInvalidSalt
+	}
+	return compareResult(dpass.Fingerprint(secret, decoded), expected)
+}
+
+func compareResult(got, want string) error {
+	if got != want {
+		return fmt.Errorf("got %s, want %s", got, want)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package shamir
+
+import (
+	"bytes"
+	"crypto/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDrillBackend(t *testing.T) {
+	mnemonic := []byte("daughter very gossip boil void ghost that obtain crew retreat obey direct " +
+		"brain bulb grow edge shield join hotel genius concert gain later account\n")
+	sb := splitBackendDefault()
+	sb.parts = 5
+	sb.threshold = 3
+	blocks, err := sb.split(mnemonic)
+	if err != nil {
+		t.Fatalf("failed to split: %v", err)
+	}
+	m, err := newManifest(mnemonic, blocks, time.Now(), rand.Reader, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create manifest: %v", err)
+	}
+
+	db := drillBackendDefault()
+	db.chain = "bitcoin"
+	db.address = "bc1qpeft30lweh28g9yaq20h0mfdjensap49l98jft"
+	var output bytes.Buffer
+	if err := db.drill(&output, m, blocks[1:4], nil); err != nil {
+		t.Fatalf("failed to drill: %v\n%s", err, output.String())
+	}
+	for _, line := range []string{"manifest: PASS", "combine: PASS", "fingerprint: PASS",
+		"bitcoin address: PASS", "drill PASSED"} {
+		if !strings.Contains(output.String(), line) {
+			t.Fatalf("missing %q in output:\n%s", line, output.String())
+		}
+	}
+	if strings.Contains(output.String(), "daughter") {
+		t.Fatalf("secret leaked in output:\n%s", output.String())
+	}
+
+	// The fingerprint may be given without the manifest, along with its salt.
+	db.fingerprint = m.Fingerprint
+	for salt, want := range map[string]string{
+		m.Salt: "fingerprint: PASS", "": "fingerprint: FAIL (invalid fingerprint salt)", "00": "fingerprint: FAIL (got",
+	} {
+		db.salt = salt
+		output.Reset()
+		_ = db.drill(&output, nil, blocks[1:4], nil)
+		if !strings.Contains(output.String(), want) {
+			t.Fatalf("missing %q in output:\n%s", want, output.String())
+		}
+	}
+	db.fingerprint, db.salt = "", ""
+
+	db.chain = "ethereum"
+	output.Reset()
+	if err := db.drill(&output, nil, blocks[:3], nil); err == nil {
+		t.Fatalf("expect error with wrong address")
+	}
+	if !strings.Contains(output.String(), "ethereum address: FAIL") ||
+		!strings.Contains(output.String(), "drill FAILED") {
+		t.Fatalf("got output:\n%s", output.String())
+	}
+
+	output.Reset()
+	if err := db.drill(&output, nil, blocks[:2], nil); err == nil {
+		t.Fatalf("expect error with not enough shares")
+	}
+	if !strings.Contains(output.String(), "combine: FAIL") {
+		t.Fatalf("got output:\n%s", output.String())
+	}
+}
+
+func TestDrillBackendDecrypt(t *testing.T) {
+	ciphertext := []byte("63636336366331363830343989f0525931a606f3d22a1fb9248b2444e8a2db37cfe3\n")
+	sb := splitBackendDefault()
+	sb.parts = 3
+	sb.threshold = 2
+	blocks, err := sb.split(ciphertext)
+	if err != nil {
+		t.Fatalf("failed to split: %v", err)
+	}
+	db := drillBackendDefault()
+	var output bytes.Buffer
+	if err := db.drill(&output, nil, blocks[1:], []byte("a7b2fa8897cf785e2e5dbca7648617d4")); err != nil {
+		t.Fatalf("failed to drill: %v\n%s", err, output.String())
+	}
+	if !strings.Contains(output.String(), "decrypt: PASS") {
+		t.Fatalf("got output:\n%s", output.String())
+	}
+	output.Reset()
+	if err := db.drill(&output, nil, blocks[1:], []byte("b7b2fa8897cf785e2e5dbca7648617d4")); err == nil {
+		t.Fatalf("expect error with wrong key")
+	}
+}
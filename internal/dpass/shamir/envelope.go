@@ -0,0 +1,160 @@
+package shamir
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/rbee3u/dpass/internal/dpass"
+)
+
+const (
+	gcmStandardNonceSize = 12
+
+	HeaderEnvelope = "E"
+	envelopeAES256 = "AES256GCM"
+)
+
+var (
+	errInvalidEnvelope    = errors.New("invalid envelope")
+	errInvalidPasswordMap = errors.New("invalid password map")
+	errMissingPassword    = errors.New("missing password")
+)
+
+// sealBlock wraps the share of a block in an AES-256-GCM envelope. The key is
+// derived from the password of the holder, and the visible headers are bound
+// to the ciphertext as additional data so that they can not be altered.
+func sealBlock(block *pem.Block, key []byte, nonceReader io.Reader) (*pem.Block, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcmStandardNonceSize)
+	if _, err := io.ReadFull(nonceReader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to read nonce: %w", err)
+	}
+	sealed := &pem.Block{Type: block.Type, Headers: maps.Clone(block.Headers)}
+	sealed.Headers[HeaderEnvelope] = envelopeAES256
+	sealed.Bytes = aead.Seal(nonce, nonce, block.Bytes, additionalData(sealed))
+	sealed.Headers[HeaderChecksum] = checksum(sealed.Bytes)
+	return sealed, nil
+}
+
+// openBlock reverses sealBlock, returning a block that holds the plain share.
+func openBlock(block *pem.Block, key []byte) (*pem.Block, error) {
+	if block.Headers[HeaderEnvelope] != envelopeAES256 || len(block.Bytes) < gcmStandardNonceSize {
+		return nil, errInvalidEnvelope
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := block.Bytes[:gcmStandardNonceSize]
+	ciphertext := block.Bytes[gcmStandardNonceSize:]
+	share, err := aead.Open(nil, nonce, ciphertext, additionalData(block))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open envelope: %w", err)
+	}
+	opened := &pem.Block{Type: block.Type, Headers: maps.Clone(block.Headers), Bytes: share}
+	delete(opened.Headers, HeaderEnvelope)
+	opened.Headers[HeaderChecksum] = checksum(share)
+	return opened, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to new block: %w", err)
+	}
+	aead, err := cipher.NewGCMWithNonceSize(block, gcmStandardNonceSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to new aead: %w", err)
+	}
+	return aead, nil
+}
+
+func additionalData(block *pem.Block) []byte {
+	var builder strings.Builder
+	builder.WriteString(block.Type)
+	for _, header := range []string{
+		HeaderSet, HeaderParts, HeaderThreshold, HeaderIndex, HeaderXs, HeaderField, HeaderEnvelope,
+	} {
+		builder.WriteString("\n" + header + ":" + block.Headers[header])
+	}
+	return []byte(builder.String())
+}
+
+// openBlocks prompts for the password of every sealed block it meets, the
+// plain blocks are passed through unchanged.
+func openBlocks(blocks []*pem.Block) ([]*pem.Block, error) {
+	opened := make([]*pem.Block, len(blocks))
+	for i, block := range blocks {
+		if _, sealed := block.Headers[HeaderEnvelope]; !sealed {
+			opened[i] = block
+			continue
+		}
+		var err error
+		if opened[i], err = openShare(block); err != nil {
+			return nil, err
+		}
+	}
+	return opened, nil
+}
+
+// openShare prompts for the password of a sealed block and opens it.
+func openShare(block *pem.Block) (*pem.Block, error) {
+	if err := checkBlock(block); err != nil {
+		return nil, fmt.Errorf("failed to check share %s: %w", block.Headers[HeaderIndex], err)
+	}
+	password, err := dpass.ReadPassword(fmt.Sprintf("Password For Share %s:", block.Headers[HeaderIndex]))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read password: %w", err)
+	}
+	opened, err := openBlock(block, dpass.DeriveKey(password))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open share %s: %w", block.Headers[HeaderIndex], err)
+	}
+	return opened, nil
+}
+
+// readPasswordMap reads a file with one "index:password" pair per line.
+func readPasswordMap(path string) (passwords map[int][]byte, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open password map: %w", err)
+	}
+	defer func() {
+		if e := file.Close(); e != nil && err == nil {
+			err = fmt.Errorf("failed to close password map: %w", e)
+		}
+	}()
+	passwords = make(map[int][]byte)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 {
+			continue
+		}
+		indexText, password, found := strings.Cut(line, ":")
+		index, err := strconv.Atoi(strings.TrimSpace(indexText))
+		if !found || err != nil || len(strings.TrimSpace(password)) == 0 {
+			return nil, errInvalidPasswordMap
+		}
+		if _, exist := passwords[index]; exist {
+			return nil, errInvalidPasswordMap
+		}
+		passwords[index] = []byte(strings.TrimSpace(password))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan password map: %w", err)
+	}
+	return passwords, nil
+}
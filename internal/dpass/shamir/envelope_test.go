@@ -0,0 +1,118 @@
+package shamir
+
+import (
+	"bytes"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rbee3u/dpass/pkg/hashx"
+)
+
+func TestSealBlock(t *testing.T) {
+	secret := []byte("To be, or not to be, that is the question.")
+	sb := splitBackendDefault()
+	sb.parts = 5
+	sb.threshold = 3
+	blocks, err := sb.split(secret)
+	if err != nil {
+		t.Fatalf("failed to split: %v", err)
+	}
+	key := []byte("a7b2fa8897cf785e2e5dbca7648617d4")
+	sealed, err := sealBlock(blocks[1], key, bytes.NewReader([]byte("ccc66c168049")))
+	if err != nil {
+		t.Fatalf("failed to seal: %v", err)
+	}
+	if sealed.Headers[HeaderEnvelope] != envelopeAES256 || bytes.Contains(sealed.Bytes, blocks[1].Bytes) {
+		t.Fatalf("got = %v, want sealed block", sealed)
+	}
+	opened, err := openBlock(sealed, key)
+	if err != nil {
+		t.Fatalf("failed to open: %v", err)
+	}
+	if !bytes.Equal(opened.Bytes, blocks[1].Bytes) || len(opened.Headers) != len(blocks[1].Headers) {
+		t.Fatalf("got = %v, want = %v", opened, blocks[1])
+	}
+	if _, err := openBlock(sealed, []byte("b7b2fa8897cf785e2e5dbca7648617d4")); err == nil {
+		t.Fatalf("expect error with wrong key")
+	}
+	sealed.Headers[HeaderIndex] = "2"
+	if _, err := openBlock(sealed, key); err == nil {
+		t.Fatalf("expect error with altered header")
+	}
+}
+
+func TestReadPasswordMap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "passwords.txt")
+	if err := os.WriteFile(path, []byte("0:alice\n\n1: bob:colon\n"), fileMode); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	passwords, err := readPasswordMap(path)
+	if err != nil {
+		t.Fatalf("failed to read password map: %v", err)
+	}
+	if len(passwords) != 2 || string(passwords[0]) != "alice" || string(passwords[1]) != "bob:colon" {
+		t.Fatalf("got = %q", passwords)
+	}
+	if err := os.WriteFile(path, []byte("0:alice\n0:bob\n"), fileMode); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	if _, err := readPasswordMap(path); err == nil {
+		t.Fatalf("expect error with duplicated index")
+	}
+}
+
+func TestSplitBackendEncrypted(t *testing.T) {
+	secret := []byte("To be, or not to be, that is the question.")
+	deriveKey := func(password []byte) []byte {
+		key := hashx.Sha256Sum(password)
+		return key[:]
+	}
+	for _, field := range []string{fieldGF256, fieldGF65536} {
+		dir := t.TempDir()
+		passwords := filepath.Join(dir, "passwords.txt")
+		if err := os.WriteFile(passwords, []byte("0:alice\n1:bob\n2:carol\n"), fileMode); err != nil {
+			t.Fatalf("failed to write: %v", err)
+		}
+		sb := splitBackendDefault()
+		sb.parts, sb.threshold, sb.field = 3, 2, field
+		sb.encrypt, sb.passwords, sb.deriveKey = true, passwords, deriveKey
+		sb.output = filepath.Join(dir, "shares", "share")
+		if err := os.Mkdir(filepath.Dir(sb.output), 0o700); err != nil {
+			t.Fatalf("failed to mkdir: %v", err)
+		}
+		sb.manifest = filepath.Join(dir, "manifest.json")
+		if err := sb.run(secret); err != nil {
+			t.Fatalf("failed to split %s: %v", field, err)
+		}
+		blocks, err := readBlockFiles([]string{filepath.Dir(sb.output)})
+		if err != nil {
+			t.Fatalf("failed to read shares: %v", err)
+		}
+		m, err := readManifest(sb.manifest)
+		if err != nil {
+			t.Fatalf("failed to read manifest: %v", err)
+		}
+		var output bytes.Buffer
+		if err := manifestCheckBackendDefault().check(&output, m, blocks); err != nil {
+			t.Fatalf("failed to check manifest: %v\n%s", err, output.String())
+		}
+		opened := make([]*pem.Block, 0, 2)
+		for _, block := range blocks[1:] {
+			password := map[string]string{"1": "bob", "2": "carol"}[block.Headers[HeaderIndex]]
+			block, err := openBlock(block, deriveKey([]byte(password)))
+			if err != nil {
+				t.Fatalf("failed to open: %v", err)
+			}
+			opened = append(opened, block)
+		}
+		combined, err := combineBackendDefault().combine(opened)
+		if err != nil {
+			t.Fatalf("failed to combine: %v", err)
+		}
+		if !bytes.Equal(combined, secret) {
+			t.Errorf("got = %s, want = %s", combined, secret)
+		}
+	}
+}
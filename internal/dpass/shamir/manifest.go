@@ -0,0 +1,217 @@
+package shamir
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/rbee3u/dpass/internal/dpass"
+	"github.com/rbee3u/dpass/pkg/hashx"
+	"github.com/spf13/cobra"
+)
+
+const manifestDefault = ""
+
+var (
+	errInvalidManifest = errors.New("invalid manifest")
+	errManifestCheck   = errors.New("manifest check failed")
+	errTooManyHolders  = errors.New("more holders or locations than parts")
+	errStaleManifest   = errors.New("stale manifest")
+)
+
+// manifest records how a secret was split, so that the shares can be audited
+// later on without being combined. It holds nothing secret.
+type manifest struct {
+	SetID       string          `json:"set_id"`
+	CreatedAt   time.Time       `json:"created_at"`
+	Parts       int             `json:"parts"`
+	Threshold   int             `json:"threshold"`
+	Field       string          `json:"field"`
+	Fingerprint string          `json:"fingerprint"`
+	Salt        string          `json:"fingerprint_salt"`
+	Shares      []manifestShare `json:"shares"`
+}
+
+type manifestShare struct {
+	Index    int    `json:"index"`
+	Hash     string `json:"hash"`
+	Holder   string `json:"holder,omitempty"`
+	Location string `json:"location,omitempty"`
+}
+
+// newManifest records the set of shares, the fingerprint of the secret is
+// salted with bytes read from saltReader.
+func newManifest(
+	secret []byte, blocks []*pem.Block, createdAt time.Time, saltReader io.Reader, holders, locations []string,
+) (*manifest, error) {
+	if len(holders) > len(blocks) || len(locations) > len(blocks) {
+		return nil, errTooManyHolders
+	}
+	set, err := checkSet(blocks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check set: %w", err)
+	}
+	salt := make([]byte, dpass.FingerprintSaltSize)
+	if _, err := io.ReadFull(saltReader, salt); err != nil {
+		return nil, fmt.Errorf("failed to read salt: %w", err)
+	}
+	m := &manifest{
+		SetID:       set.id,
+		CreatedAt:   createdAt.UTC().Truncate(time.Second),
+		Parts:       set.parts,
+		Threshold:   set.threshold,
+		Field:       set.field,
+		Fingerprint: dpass.Fingerprint(secret, salt),
+		Salt:        hex.EncodeToString(salt),
+		Shares:      make([]manifestShare, len(blocks)),
+	}
+	for index, block := range blocks {
+		m.Shares[index] = manifestShare{Index: index, Hash: shareHash(block)}
+		if index < len(holders) {
+			m.Shares[index].Holder = holders[index]
+		}
+		if index < len(locations) {
+			m.Shares[index].Location = locations[index]
+		}
+	}
+	return m, nil
+}
+
+// extend records the shares issued by share-extend, which must follow the
+// last share of the manifest, the fingerprint of the secret stays the same.
+func (m *manifest) extend(blocks []*pem.Block, holders, locations []string) error {
+	if len(holders) > len(blocks) || len(locations) > len(blocks) {
+		return errTooManyHolders
+	}
+	for i, block := range blocks {
+		index, err := strconv.Atoi(block.Headers[HeaderIndex])
+		if err != nil || block.Headers[HeaderSet] != m.SetID || index != len(m.Shares) {
+			return errStaleManifest
+		}
+		share := manifestShare{Index: index, Hash: shareHash(block)}
+		if i < len(holders) {
+			share.Holder = holders[i]
+		}
+		if i < len(locations) {
+			share.Location = locations[i]
+		}
+		m.Shares = append(m.Shares, share)
+	}
+	m.Parts = len(m.Shares)
+	return nil
+}
+
+// shareHash covers the headers as well as the bytes of a share, exactly as
+// they are written in the share file.
+func shareHash(block *pem.Block) string {
+	return hex.EncodeToString(hashx.Sha256Sum(pem.EncodeToMemory(block)))
+}
+
+func writeManifest(path string, m *manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), fileMode); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+func readManifest(path string) (*manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal manifest: %w", err)
+	}
+	if len(m.Shares) != m.Parts {
+		return nil, errInvalidManifest
+	}
+	for index := range m.Shares {
+		if m.Shares[index].Index != index {
+			return nil, errInvalidManifest
+		}
+	}
+	return &m, nil
+}
+
+func NewCmdManifest() *cobra.Command {
+	cmd := &cobra.Command{Use: "manifest", Args: cobra.NoArgs}
+	cmd.AddCommand(newCmdManifestCheck())
+	return cmd
+}
+
+type manifestCheckBackend struct{}
+
+func manifestCheckBackendDefault() *manifestCheckBackend {
+	return &manifestCheckBackend{}
+}
+
+func newCmdManifestCheck() *cobra.Command {
+	backend := manifestCheckBackendDefault()
+	cmd := &cobra.Command{
+		Use:  "check manifest [file or directory]...",
+		Args: cobra.MinimumNArgs(1),
+		RunE: backend.runE,
+	}
+	return cmd
+}
+
+func (b *manifestCheckBackend) runE(_ *cobra.Command, args []string) error {
+	m, err := readManifest(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read manifest: %w", err)
+	}
+	var blocks []*pem.Block
+	if len(args) > 1 {
+		blocks, err = readBlockFiles(args[1:])
+	} else {
+		blocks, err = readBlocks(os.Stdin)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read shares: %w", err)
+	}
+	return b.check(os.Stdout, m, blocks)
+}
+
+// check verifies every share against the manifest and reports the result of
+// each one, it fails if any share does not match.
+func (b *manifestCheckBackend) check(w io.Writer, m *manifest, blocks []*pem.Block) error {
+	failed, valid := false, make(map[int]bool)
+	for _, block := range blocks {
+		index, err := strconv.Atoi(block.Headers[HeaderIndex])
+		name := "share " + block.Headers[HeaderIndex]
+		if err == nil && index >= 0 && index < len(m.Shares) && len(m.Shares[index].Holder) != 0 {
+			name += " (" + m.Shares[index].Holder + ")"
+		}
+		switch {
+		case block.Type != BlockType:
+			_, _ = fmt.Fprintf(w, "%s: FAIL, not a share\n", name)
+		case block.Headers[HeaderSet] != m.SetID:
+			_, _ = fmt.Fprintf(w, "%s: FAIL, belongs to set %q\n", name, block.Headers[HeaderSet])
+		case err != nil || index < 0 || index >= len(m.Shares):
+			_, _ = fmt.Fprintf(w, "%s: FAIL, unknown index, the manifest may be stale\n", name)
+		case shareHash(block) != m.Shares[index].Hash:
+			_, _ = fmt.Fprintf(w, "%s: FAIL, hash mismatch\n", name)
+		default:
+			_, _ = fmt.Fprintf(w, "%s: OK\n", name)
+			valid[index] = true
+			continue
+		}
+		failed = true
+	}
+	_, _ = fmt.Fprintf(w, "%v of %v valid shares, %v needed to reconstruct\n", len(valid), m.Parts, m.Threshold)
+	if failed {
+		return errManifestCheck
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package shamir
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rbee3u/dpass/internal/dpass"
+)
+
+func TestManifest(t *testing.T) {
+	secret := []byte("To be, or not to be, that is the question.")
+	sb := splitBackendDefault()
+	sb.parts = 5
+	sb.threshold = 3
+	blocks, err := sb.split(secret)
+	if err != nil {
+		t.Fatalf("failed to split: %v", err)
+	}
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	m, err := newManifest(secret, blocks, createdAt, rand.Reader, []string{"alice", "bob"}, []string{"bank"})
+	if err != nil {
+		t.Fatalf("failed to create manifest: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	if err := writeManifest(path, m); err != nil {
+		t.Fatalf("failed to write manifest: %v", err)
+	}
+	m, err = readManifest(path)
+	if err != nil {
+		t.Fatalf("failed to read manifest: %v", err)
+	}
+	if m.Parts != 5 || m.Threshold != 3 || !m.CreatedAt.Equal(createdAt) ||
+		m.Shares[1].Holder != "bob" || m.Shares[0].Location != "bank" || m.Shares[4].Holder != "" {
+		t.Fatalf("got = %+v", m)
+	}
+	// The salt keeps the fingerprints of the same secret apart.
+	other, err := newManifest(secret, blocks, createdAt, rand.Reader, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create manifest: %v", err)
+	}
+	if len(m.Salt) != 2*dpass.FingerprintSaltSize || other.Salt == m.Salt || other.Fingerprint == m.Fingerprint {
+		t.Fatalf("got = %+v, want = %+v", other, m)
+	}
+	if _, err := newManifest(secret, blocks, createdAt, rand.Reader, make([]string, 6), nil); err == nil {
+		t.Fatalf("expect error with too many holders")
+	}
+
+	mb := manifestCheckBackendDefault()
+	var output bytes.Buffer
+	if err := mb.check(&output, m, []*pem.Block{blocks[4], blocks[1]}); err != nil {
+		t.Fatalf("failed to check: %v\n%s", err, output.String())
+	}
+	if !strings.Contains(output.String(), "share 1 (bob): OK") ||
+		!strings.Contains(output.String(), "2 of 5 valid shares, 3 needed") {
+		t.Fatalf("got output:\n%s", output.String())
+	}
+
+	others, err := sb.split(secret)
+	if err != nil {
+		t.Fatalf("failed to split: %v", err)
+	}
+	tampered := *blocks[2]
+	tampered.Bytes = bytes.Clone(blocks[2].Bytes)
+	tampered.Bytes[0] ^= 1
+	output.Reset()
+	if err := mb.check(&output, m, []*pem.Block{blocks[0], &tampered, others[3]}); err == nil {
+		t.Fatalf("expect error with tampered share")
+	}
+	if !strings.Contains(output.String(), "share 2: FAIL, hash mismatch") ||
+		!strings.Contains(output.String(), "share 3: FAIL, belongs to set") {
+		t.Fatalf("got output:\n%s", output.String())
+	}
+}
+
+func TestManifestReshardExtend(t *testing.T) {
+	secret := []byte("To be, or not to be, that is the question.")
+	sb := splitBackendDefault()
+	blocks, err := sb.split(secret)
+	if err != nil {
+		t.Fatalf("failed to split: %v", err)
+	}
+	rb := reshardBackendDefault()
+	rb.splitBackend.parts, rb.splitBackend.threshold = 4, 3
+	rb.splitBackend.manifest = filepath.Join(t.TempDir(), "manifest.json")
+	rb.splitBackend.holders = []string{"alice"}
+	resharded, m, err := rb.reshard(blocks[:2])
+	if err != nil {
+		t.Fatalf("failed to reshard: %v", err)
+	}
+	if m == nil || m.Parts != 4 || m.Threshold != 3 || m.SetID != resharded[0].Headers[HeaderSet] {
+		t.Fatalf("got = %+v", m)
+	}
+
+	eb := extendBackendDefault()
+	eb.count = 2
+	extended, err := eb.extend(resharded[1:4])
+	if err != nil {
+		t.Fatalf("failed to extend: %v", err)
+	}
+	stale := *m
+	stale.Shares = slices.Clone(m.Shares)
+	if err := m.extend(extended, []string{"carol", "dave"}, nil); err != nil {
+		t.Fatalf("failed to extend manifest: %v", err)
+	}
+	if err := m.extend(extended, nil, nil); !errors.Is(err, errStaleManifest) {
+		t.Fatalf("got = %v, want = %v", err, errStaleManifest)
+	}
+	var output bytes.Buffer
+	if err := manifestCheckBackendDefault().check(&output, m, append(resharded, extended...)); err != nil {
+		t.Fatalf("failed to check: %v\n%s", err, output.String())
+	}
+	if !strings.Contains(output.String(), "share 0 (alice): OK") ||
+		!strings.Contains(output.String(), "share 5 (dave): OK") ||
+		!strings.Contains(output.String(), "6 of 6 valid shares, 3 needed") {
+		t.Fatalf("got output:\n%s", output.String())
+	}
+	output.Reset()
+	if err := manifestCheckBackendDefault().check(&output, &stale, extended); err == nil ||
+		!strings.Contains(output.String(), "the manifest may be stale") {
+		t.Fatalf("got output:\n%s", output.String())
+	}
+}
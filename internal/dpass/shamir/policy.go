@@ -0,0 +1,339 @@
+package shamir
+
+import (
+	"bytes"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rbee3u/dpass/third_party/github.com/hashicorp/vault/shamir"
+)
+
+// A policy is a tree of threshold gates whose leaves are holders, e.g.
+//
+//	or(ceo, 2of(alice, bob, carol), 4of(s1, s2, s3, s4, s5, s6))
+//
+// "and" and "or" are shorthands for all-of and 1of, and every node may carry
+// a weight like "ceo:2", counting as that many shares of its parent gate.
+//
+// A gate is compiled into a single Shamir split with as many shares as the sum
+// of the weights of its children, and each child receives its own shares as a
+// secret to be split further down. A holder ends up with one block per leaf.
+const (
+	PolicyBlockType = "SHAMIR POLICY"
+	HeaderPolicy    = "P"
+	HeaderHolder    = "H"
+	HeaderPath      = "T"
+
+	policyDefault = ""
+)
+
+var (
+	errInvalidPolicy      = errors.New("invalid policy")
+	errPolicyNotSatisfied = errors.New("policy not satisfied")
+	errUnsupportedPolicy  = errors.New("unsupported option with policy")
+)
+
+type policyNode struct {
+	threshold int
+	weight    int
+	holder    string
+	children  []*policyNode
+}
+
+func (n *policyNode) isLeaf() bool {
+	return n.children == nil
+}
+
+func (n *policyNode) totalWeight() int {
+	total := 0
+	for _, child := range n.children {
+		total += child.weight
+	}
+	return total
+}
+
+func (n *policyNode) String() string {
+	var builder strings.Builder
+	if n.isLeaf() {
+		builder.WriteString(n.holder)
+	} else {
+		builder.WriteString(strconv.Itoa(n.threshold) + "of(")
+		for i, child := range n.children {
+			if i > 0 {
+				builder.WriteString(",")
+			}
+			builder.WriteString(child.String())
+		}
+		builder.WriteString(")")
+	}
+	if n.weight != 1 {
+		builder.WriteString(":" + strconv.Itoa(n.weight))
+	}
+	return builder.String()
+}
+
+type policyParser struct {
+	text string
+	pos  int
+}
+
+func parsePolicy(text string) (*policyNode, error) {
+	p := &policyParser{text: text}
+	node, err := p.parseNode()
+	if err != nil {
+		return nil, err
+	}
+	if p.skipSpaces(); p.pos != len(p.text) {
+		return nil, fmt.Errorf("unexpected %q at %v: %w", p.text[p.pos:], p.pos, errInvalidPolicy)
+	}
+	return node, nil
+}
+
+func (p *policyParser) skipSpaces() {
+	for p.pos < len(p.text) {
+		r, size := utf8.DecodeRuneInString(p.text[p.pos:])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		p.pos += size
+	}
+}
+
+func (p *policyParser) consume(c byte) bool {
+	if p.skipSpaces(); p.pos < len(p.text) && p.text[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *policyParser) parseWord() string {
+	p.skipSpaces()
+	start := p.pos
+	// Holders may be named in any script, so the text is read rune by rune.
+	for p.pos < len(p.text) {
+		r, size := utf8.DecodeRuneInString(p.text[p.pos:])
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' && r != '.' {
+			break
+		}
+		p.pos += size
+	}
+	return p.text[start:p.pos]
+}
+
+func (p *policyParser) parseNode() (*policyNode, error) {
+	start := p.pos
+	word := p.parseWord()
+	if len(word) == 0 {
+		return nil, fmt.Errorf("expect name at %v: %w", p.pos, errInvalidPolicy)
+	}
+	node := &policyNode{weight: 1}
+	if p.consume('(') {
+		for {
+			child, err := p.parseNode()
+			if err != nil {
+				return nil, err
+			}
+			node.children = append(node.children, child)
+			if p.consume(')') {
+				break
+			}
+			if !p.consume(',') {
+				return nil, fmt.Errorf("expect ',' or ')' at %v: %w", p.pos, errInvalidPolicy)
+			}
+		}
+		switch {
+		case word == "and":
+			node.threshold = node.totalWeight()
+		case word == "or":
+			node.threshold = 1
+		case strings.HasSuffix(word, "of"):
+			threshold, err := strconv.Atoi(strings.TrimSuffix(word, "of"))
+			if err != nil || threshold < 1 || threshold > node.totalWeight() {
+				return nil, fmt.Errorf("invalid gate %q at %v: %w", word, start, errInvalidPolicy)
+			}
+			node.threshold = threshold
+		default:
+			return nil, fmt.Errorf("unknown gate %q at %v: %w", word, start, errInvalidPolicy)
+		}
+	} else {
+		node.holder = word
+	}
+	if p.consume(':') {
+		weight, err := strconv.Atoi(p.parseWord())
+		if err != nil || weight < 1 {
+			return nil, fmt.Errorf("invalid weight at %v: %w", p.pos, errInvalidPolicy)
+		}
+		node.weight = weight
+	}
+	return node, nil
+}
+
+// compilePolicy splits the secret down the tree, returning one block per leaf.
+// Like the shares of a plain split, every block carries the set id and a
+// checksum, so that leaves of different splits of the same policy are not
+// mixed up.
+func compilePolicy(random io.Reader, root *policyNode, secret []byte) ([]*pem.Block, error) {
+	id, err := newSetID(random)
+	if err != nil {
+		return nil, err
+	}
+	policy := root.String()
+	var blocks []*pem.Block
+	var walk func(node *policyNode, path []int, secret []byte) error
+	walk = func(node *policyNode, path []int, secret []byte) error {
+		if node.isLeaf() {
+			blocks = append(blocks, &pem.Block{
+				Type: PolicyBlockType,
+				Headers: map[string]string{
+					HeaderSet:      id,
+					HeaderPolicy:   policy,
+					HeaderHolder:   node.holder,
+					HeaderPath:     formatPath(path),
+					HeaderChecksum: checksum(secret),
+				},
+				Bytes: secret,
+			})
+			return nil
+		}
+		var shares [][]byte
+		if node.threshold > 1 {
+			var err error
+			if shares, err = shamir.SplitWithReader(random, secret, node.totalWeight(), node.threshold); err != nil {
+				return fmt.Errorf("failed to split gate %v: %w", formatPath(path), err)
+			}
+		}
+		offset := 0
+		for i, child := range node.children {
+			// Any child of a 1of gate can recover the secret on its own.
+			childSecret := secret
+			if shares != nil {
+				childSecret = bytes.Join(shares[offset:offset+child.weight], nil)
+				offset += child.weight
+			}
+			if err := walk(child, append(slices.Clone(path), i), childSecret); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(root, nil, secret); err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+// combinePolicy reconstructs the secret from the leaves at hand, it returns
+// the holders whose blocks are actually needed along with the secret.
+func combinePolicy(blocks []*pem.Block) ([]byte, []string, error) {
+	id, policy := "", ""
+	leaves := make(map[string]*pem.Block, len(blocks))
+	for _, block := range blocks {
+		if block.Type != PolicyBlockType {
+			return nil, nil, errInvalidBlockType
+		}
+		_, hasSet := block.Headers[HeaderSet]
+		if _, hasChecksum := block.Headers[HeaderChecksum]; !hasSet || !hasChecksum {
+			return nil, nil, fmt.Errorf("failed to check %s: %w", block.Headers[HeaderHolder], errInvalidHeader)
+		}
+		if err := checkBlock(block); err != nil {
+			return nil, nil, fmt.Errorf("failed to check %s: %w", block.Headers[HeaderHolder], err)
+		}
+		if len(policy) != 0 && (id != block.Headers[HeaderSet] || policy != block.Headers[HeaderPolicy]) {
+			return nil, nil, errInconsistentSet
+		}
+		id, policy = block.Headers[HeaderSet], block.Headers[HeaderPolicy]
+		leaves[block.Headers[HeaderPath]] = block
+	}
+	root, err := parsePolicy(policy)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse policy: %w", err)
+	}
+	var walk func(node *policyNode, path []int) ([]byte, []string, error)
+	walk = func(node *policyNode, path []int) ([]byte, []string, error) {
+		if node.isLeaf() {
+			leaf, exist := leaves[formatPath(path)]
+			if !exist || leaf.Headers[HeaderHolder] != node.holder {
+				return nil, nil, nil
+			}
+			return leaf.Bytes, []string{node.holder}, nil
+		}
+		var shares [][]byte
+		var holders []string
+		count := 0
+		for i, child := range node.children {
+			secret, used, err := walk(child, append(slices.Clone(path), i))
+			if err != nil {
+				return nil, nil, err
+			}
+			if secret == nil {
+				continue
+			}
+			if node.threshold == 1 {
+				return secret, used, nil
+			}
+			if len(secret)%child.weight != 0 {
+				return nil, nil, fmt.Errorf("failed to cut shares of %v: %w", formatPath(path), errInvalidHeader)
+			}
+			size := len(secret) / child.weight
+			for j := range child.weight {
+				shares = append(shares, secret[j*size:(j+1)*size])
+			}
+			holders = append(holders, used...)
+			if count += child.weight; count >= node.threshold {
+				secret, err := shamir.Combine(shares)
+				if err != nil {
+					return nil, nil, fmt.Errorf("failed to combine gate %v: %w", formatPath(path), err)
+				}
+				return secret, holders, nil
+			}
+		}
+		return nil, nil, nil
+	}
+	secret, holders, err := walk(root, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if secret == nil {
+		return nil, nil, errPolicyNotSatisfied
+	}
+	return secret, holders, nil
+}
+
+func formatPath(path []int) string {
+	fields := make([]string, len(path))
+	for i, v := range path {
+		fields[i] = strconv.Itoa(v)
+	}
+	return "/" + strings.Join(fields, "/")
+}
+
+func writePolicyBlocks(output string, blocks []*pem.Block) error {
+	if len(output) == 0 {
+		return writeBlocks(output, blocks)
+	}
+	var holders []string
+	files := make(map[string][]byte)
+	for _, block := range blocks {
+		holder := block.Headers[HeaderHolder]
+		if _, exist := files[holder]; !exist {
+			holders = append(holders, holder)
+		}
+		files[holder] = append(files[holder], pem.EncodeToMemory(block)...)
+	}
+	for _, holder := range holders {
+		path := fmt.Sprintf("%s-%s.txt", output, holder)
+		if err := os.WriteFile(path, files[holder], fileMode); err != nil {
+			return fmt.Errorf("failed to write blocks: %w", err)
+		}
+	}
+	return nil
+}
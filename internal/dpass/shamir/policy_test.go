@@ -0,0 +1,129 @@
+package shamir
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"testing"
+)
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		text   string
+		policy string
+	}{
+		{text: "or(ceo, 2of(d1, d2, d3))", policy: "1of(ceo,2of(d1,d2,d3))"},
+		{text: " and ( a , b:2 ) ", policy: "3of(a,b:2)"},
+		{text: "3of(ceo:3, 2of(a,b,c):2, x)", policy: "3of(ceo:3,2of(a,b,c):2,x)"},
+		{text: "2of(José, 张伟,\u3000Zoë)", policy: "2of(José,张伟,Zoë)"},
+	}
+	for _, tt := range tests {
+		root, err := parsePolicy(tt.text)
+		if err != nil {
+			t.Fatalf("failed to parse policy: %v", err)
+		}
+		if policy := root.String(); policy != tt.policy {
+			t.Errorf("got = %s, want = %s", policy, tt.policy)
+		}
+	}
+	for _, text := range []string{"", "or(", "or()", "4of(a,b,c)", "0of(a,b)", "xof(a,b)", "foo(a,b)", "or(a,b) c", "or(a:0,b)"} {
+		if _, err := parsePolicy(text); err == nil {
+			t.Errorf("expect error with policy %q", text)
+		}
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	secret := []byte("To be, or not to be, that is the question.")
+	sb := splitBackendDefault()
+	sb.policy = "or(ceo, 2of(d1, d2, d3), 4of(s1, s2, s3, s4, s5), 3of(boss:2, helper1, helper2))"
+	blocks, err := sb.splitPolicy(secret)
+	if err != nil {
+		t.Fatalf("failed to split: %v", err)
+	}
+	byHolder := make(map[string]*pem.Block)
+	for _, block := range blocks {
+		byHolder[block.Headers[HeaderHolder]] = block
+	}
+	tests := []struct {
+		holders   []string
+		satisfied bool
+	}{
+		{holders: []string{"ceo"}, satisfied: true},
+		{holders: []string{"d1", "d3"}, satisfied: true},
+		{holders: []string{"d2", "s1", "s2", "s3"}, satisfied: false},
+		{holders: []string{"s5", "s1", "s2", "s3"}, satisfied: true},
+		{holders: []string{"boss", "helper2"}, satisfied: true},
+		{holders: []string{"helper1", "helper2"}, satisfied: false},
+		{holders: []string{"boss"}, satisfied: false},
+	}
+	for _, tt := range tests {
+		var group []*pem.Block
+		for _, holder := range tt.holders {
+			group = append(group, byHolder[holder])
+		}
+		combinedSecret, _, err := combinePolicy(group)
+		if !tt.satisfied {
+			if err == nil {
+				t.Fatalf("expect error with holders %v", tt.holders)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("failed to combine with holders %v: %v", tt.holders, err)
+		}
+		if !bytes.Equal(combinedSecret, secret) {
+			t.Fatalf("got = %v, want = %v", combinedSecret, secret)
+		}
+	}
+}
+
+func TestPolicyNested(t *testing.T) {
+	secret := []byte("nested")
+	root, err := parsePolicy("and(2of(a, b, c), or(x, 2of(y, z)))")
+	if err != nil {
+		t.Fatalf("failed to parse policy: %v", err)
+	}
+	blocks, err := compilePolicy(rand.Reader, root, secret)
+	if err != nil {
+		t.Fatalf("failed to compile policy: %v", err)
+	}
+	// a, b, c, x, y, z in order of the leaves
+	combinedSecret, holders, err := combinePolicy([]*pem.Block{blocks[0], blocks[2], blocks[4], blocks[5]})
+	if err != nil {
+		t.Fatalf("failed to combine: %v", err)
+	}
+	if !bytes.Equal(combinedSecret, secret) || len(holders) != 4 {
+		t.Fatalf("got = %v by %v, want = %v", combinedSecret, holders, secret)
+	}
+	if _, _, err := combinePolicy([]*pem.Block{blocks[0], blocks[1], blocks[4]}); err == nil {
+		t.Fatalf("expect error when the second branch is not satisfied")
+	}
+}
+
+func TestPolicySet(t *testing.T) {
+	root, err := parsePolicy("2of(a, b, c)")
+	if err != nil {
+		t.Fatalf("failed to parse policy: %v", err)
+	}
+	blocks, err := compilePolicy(rand.Reader, root, []byte("first"))
+	if err != nil {
+		t.Fatalf("failed to compile policy: %v", err)
+	}
+	others, err := compilePolicy(rand.Reader, root, []byte("other"))
+	if err != nil {
+		t.Fatalf("failed to compile policy: %v", err)
+	}
+	if _, _, err := combinePolicy([]*pem.Block{blocks[0], others[1]}); !errors.Is(err, errInconsistentSet) {
+		t.Errorf("got = %v, want = %v", err, errInconsistentSet)
+	}
+	delete(blocks[1].Headers, HeaderChecksum)
+	if _, _, err := combinePolicy([]*pem.Block{blocks[0], blocks[1]}); !errors.Is(err, errInvalidHeader) {
+		t.Errorf("got = %v, want = %v", err, errInvalidHeader)
+	}
+	blocks[2].Bytes[0] ^= 1
+	if _, _, err := combinePolicy([]*pem.Block{blocks[0], blocks[2]}); !errors.Is(err, errChecksumMismatch) {
+		t.Errorf("got = %v, want = %v", err, errChecksumMismatch)
+	}
+}
@@ -0,0 +1,243 @@
+package shamir
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/rbee3u/dpass/pkg/bip3x"
+	"github.com/rbee3u/dpass/pkg/hashx"
+)
+
+const (
+	formatDefault = formatPEM
+	formatPEM     = "pem"
+	formatWords   = "words"
+
+	wordsPerLine = 6
+)
+
+var (
+	errInvalidFormat     = errors.New("invalid format")
+	errUnsupportedFormat = errors.New("unsupported format")
+	errInvalidWords      = errors.New("invalid words")
+)
+
+// encodeWords turns a share over GF(2^8) into BIP39 words, the words carry the
+// set id, the threshold, the index, the coordinates, the share with its x tag
+// and a checksum, so that a share can be written down or engraved by hand.
+func encodeWords(block *pem.Block) ([]string, error) {
+	set, err := checkSet([]*pem.Block{block})
+	if err != nil {
+		return nil, fmt.Errorf("failed to check share: %w", err)
+	}
+	if _, exist := block.Headers[HeaderEnvelope]; exist || set.field != fieldGF256 {
+		return nil, errUnsupportedFormat
+	}
+	id, err := hex.DecodeString(set.id)
+	if err != nil || len(id) != setIDSize || set.threshold > 255 || set.xs == nil {
+		return nil, errUnsupportedFormat
+	}
+	index, _ := strconv.Atoi(block.Headers[HeaderIndex])
+	payload := append(id, byte(set.threshold), byte(index), byte(len(set.xs)))
+	payload = append(payload, set.xs...)
+	payload = binary.AppendUvarint(payload, uint64(len(block.Bytes)))
+	payload = append(payload, block.Bytes...)
+	payload = append(payload, hashx.Sha256Sum(payload)[:checksumSize]...)
+	return payloadToWords(payload), nil
+}
+
+// payloadToWords packs the payload into words of eleven bits, the last word
+// is padded with zero bits.
+func payloadToWords(payload []byte) []string {
+	words := make([]string, 0, (len(payload)*bip3x.BitsPerByte+bip3x.BitsPerWord-1)/bip3x.BitsPerWord)
+	remain, shift := uint32(0), 0
+	for _, b := range payload {
+		remain, shift = (remain<<bip3x.BitsPerByte)|uint32(b), shift+bip3x.BitsPerByte
+		if shift >= bip3x.BitsPerWord {
+			shift -= bip3x.BitsPerWord
+			words = append(words, bip3x.ValueToWord(remain>>shift))
+			remain &= 1<<shift - 1
+		}
+	}
+	if shift > 0 {
+		words = append(words, bip3x.ValueToWord(remain<<(bip3x.BitsPerWord-shift)))
+	}
+	return words
+}
+
+// decodeWords is the reverse of encodeWords, each word may be abbreviated to
+// its first letters, and a mistyped word is reported with a suggestion.
+func decodeWords(words []string) (*pem.Block, error) {
+	payload := make([]byte, 0, len(words)*bip3x.BitsPerWord/bip3x.BitsPerByte)
+	remain, shift := uint32(0), 0
+	for position, word := range words {
+		value, err := bip3x.WordToValue(word)
+		if err != nil {
+			return nil, fmt.Errorf("word %v %q is unknown, did you mean %q: %w",
+				position+1, word, bip3x.SuggestWord(word), errInvalidWords)
+		}
+		remain, shift = (remain<<bip3x.BitsPerWord)|value, shift+bip3x.BitsPerWord
+		for shift >= bip3x.BitsPerByte {
+			shift -= bip3x.BitsPerByte
+			payload = append(payload, byte(remain>>shift))
+			remain &= 1<<shift - 1
+		}
+	}
+	if remain != 0 {
+		return nil, errChecksumMismatch
+	}
+	const headerSize = setIDSize + 3
+	if len(payload) < headerSize {
+		return nil, errInvalidWords
+	}
+	id, threshold, index, parts := payload[:setIDSize], int(payload[setIDSize]),
+		int(payload[setIDSize+1]), int(payload[setIDSize+2])
+	rest := payload[headerSize:]
+	if len(rest) < parts {
+		return nil, errInvalidWords
+	}
+	xs, rest := rest[:parts], rest[parts:]
+	size, n := binary.Uvarint(rest)
+	// The size is compared with what is left so that a huge one can not wrap.
+	if n <= 0 || len(rest)-n < checksumSize || size > uint64(len(rest)-n-checksumSize) {
+		return nil, errInvalidWords
+	}
+	share, rest := rest[n:n+int(size)], rest[n+int(size):]
+	end := len(payload) - len(rest) + checksumSize
+	// The padding of the last word may spill over into at most one zero byte.
+	if len(rest) > checksumSize+1 || (len(rest) > checksumSize && rest[checksumSize] != 0) {
+		return nil, errInvalidWords
+	}
+	if !bytes.Equal(hashx.Sha256Sum(payload[:end-checksumSize])[:checksumSize], payload[end-checksumSize:end]) {
+		return nil, errChecksumMismatch
+	}
+	set := &shareSet{
+		id:        hex.EncodeToString(id),
+		parts:     parts,
+		threshold: threshold,
+		field:     fieldGF256,
+		xs:        bytes.Clone(xs),
+	}
+	block := newBlock(set, index, bytes.Clone(share))
+	if _, err := checkSet([]*pem.Block{block}); err != nil {
+		return nil, fmt.Errorf("failed to check share: %w", err)
+	}
+	return block, nil
+}
+
+// layoutWords lays out the words a few per line, each line starts with the
+// position of its first word to help holders keep their place.
+func layoutWords(words []string) string {
+	var builder strings.Builder
+	for i := 0; i < len(words); i += wordsPerLine {
+		_, _ = fmt.Fprintf(&builder, "%3d. %s\n", i+1, strings.Join(words[i:min(i+wordsPerLine, len(words))], " "))
+	}
+	return builder.String()
+}
+
+// parseWordShares reads the word shares separated by blank lines, the
+// positions written by layoutWords and lines starting with "#" are ignored.
+func parseWordShares(data []byte) ([]*pem.Block, error) {
+	var blocks []*pem.Block
+	var words []string
+	flush := func() error {
+		if len(words) == 0 {
+			return nil
+		}
+		block, err := decodeWords(words)
+		if err != nil {
+			return fmt.Errorf("failed to decode share %v: %w", len(blocks)+1, err)
+		}
+		blocks, words = append(blocks, block), nil
+		return nil
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		words = append(words, lineWords(line)...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan words: %w", err)
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+func lineWords(line string) []string {
+	if strings.HasPrefix(line, "#") {
+		return nil
+	}
+	var words []string
+	for _, field := range strings.Fields(line) {
+		if _, err := strconv.Atoi(strings.TrimSuffix(field, ".")); err == nil {
+			continue
+		}
+		words = append(words, field)
+	}
+	return words
+}
+
+// decodeShares decodes the PEM blocks of the data, or its word shares if
+// there is no PEM block at all and the data looks like words, so that other
+// files such as the manifest lying next to the shares are left alone.
+func decodeShares(data []byte) ([]*pem.Block, error) {
+	if blocks := decodeBlocks(data); len(blocks) != 0 || !looksLikeWords(data) {
+		return blocks, nil
+	}
+	return parseWordShares(data)
+}
+
+func looksLikeWords(data []byte) bool {
+	found := false
+	for line := range strings.Lines(string(data)) {
+		for _, word := range lineWords(strings.TrimSpace(line)) {
+			if strings.IndexFunc(word, func(r rune) bool { return !unicode.IsLetter(r) }) >= 0 {
+				return false
+			}
+			found = true
+		}
+	}
+	return found
+}
+
+func writeWordShares(output string, blocks []*pem.Block) error {
+	for i, block := range blocks {
+		words, err := encodeWords(block)
+		if err != nil {
+			return fmt.Errorf("failed to encode share %v: %w", block.Headers[HeaderIndex], err)
+		}
+		if len(output) == 0 {
+			if i != 0 {
+				_, err = fmt.Fprintln(os.Stdout)
+			}
+			if err == nil {
+				_, err = os.Stdout.WriteString(layoutWords(words))
+			}
+		} else {
+			path := fmt.Sprintf("%s-%v-%v-%v.txt", output,
+				block.Headers[HeaderParts], block.Headers[HeaderThreshold], block.Headers[HeaderIndex])
+			err = os.WriteFile(path, []byte(layoutWords(words)), fileMode)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write words: %w", err)
+		}
+	}
+	return nil
+}
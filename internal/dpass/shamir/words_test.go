@@ -0,0 +1,124 @@
+package shamir
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestWords(t *testing.T) {
+	secret := []byte("To be, or not to be, that is the question.")
+	sb := splitBackendDefault()
+	sb.parts = 5
+	sb.threshold = 3
+	blocks, err := sb.split(secret)
+	if err != nil {
+		t.Fatalf("failed to split: %v", err)
+	}
+	var text []string
+	for _, block := range blocks {
+		words, err := encodeWords(block)
+		if err != nil {
+			t.Fatalf("failed to encode words: %v", err)
+		}
+		decoded, err := decodeWords(words)
+		if err != nil {
+			t.Fatalf("failed to decode words: %v", err)
+		}
+		if !bytes.Equal(pem.EncodeToMemory(decoded), pem.EncodeToMemory(block)) {
+			t.Fatalf("got = %s, want = %s", pem.EncodeToMemory(decoded), pem.EncodeToMemory(block))
+		}
+		text = append(text, layoutWords(words))
+	}
+
+	// Engraved shares keep only the first letters of each word.
+	var abbreviated []string
+	for _, field := range strings.Fields(text[3]) {
+		abbreviated = append(abbreviated, field[:min(len(field), 4)])
+	}
+	data := []byte("# share of alice\n" + text[0] + "\n\n" + strings.Join(abbreviated, " ") + "\n\n" + text[4])
+	decoded, err := decodeShares(data)
+	if err != nil {
+		t.Fatalf("failed to decode shares: %v", err)
+	}
+	combinedSecret, err := combineBackendDefault().combine(append(decoded[:2], blocks[1]))
+	if err != nil {
+		t.Fatalf("failed to combine: %v", err)
+	}
+	if !bytes.Equal(combinedSecret, secret) {
+		t.Fatalf("got = %v, want = %v", combinedSecret, secret)
+	}
+
+	words, _ := encodeWords(blocks[2])
+	mistyped := strings.Replace(strings.Join(words, " "), words[5], words[5][:2]+"qx", 1)
+	if _, err := decodeWords(strings.Fields(mistyped)); !errors.Is(err, errInvalidWords) ||
+		!strings.Contains(err.Error(), "word 6") {
+		t.Fatalf("got = %v, want = %v", err, errInvalidWords)
+	}
+	swapped := append([]string{}, words...)
+	swapped[7], swapped[8] = swapped[8], swapped[7]
+	if swapped[7] == swapped[8] {
+		swapped[7] = "zoo"
+	}
+	if _, err := decodeWords(swapped); err == nil {
+		t.Fatalf("expect error with swapped words")
+	}
+	if _, err := decodeWords(words[:len(words)-1]); err == nil {
+		t.Fatalf("expect error with missing word")
+	}
+
+	// A share size close to 2^64 must not wrap around the remaining length.
+	payload := append(bytes.Repeat([]byte{0xab}, setIDSize), 2, 0, 1, 1)
+	payload = binary.AppendUvarint(payload, math.MaxUint64-1)
+	payload = append(payload, 1, 2, 3, 4, 5)
+	if _, err := decodeWords(payloadToWords(payload)); !errors.Is(err, errInvalidWords) {
+		t.Fatalf("got = %v, want = %v", err, errInvalidWords)
+	}
+
+	sb.field = fieldGF65536
+	others, err := sb.split(secret)
+	if err != nil {
+		t.Fatalf("failed to split: %v", err)
+	}
+	if _, err := encodeWords(others[0]); !errors.Is(err, errUnsupportedFormat) {
+		t.Fatalf("got = %v, want = %v", err, errUnsupportedFormat)
+	}
+}
+
+func TestCollectWords(t *testing.T) {
+	secret := []byte("To be, or not to be, that is the question.")
+	sb := splitBackendDefault()
+	blocks, err := sb.split(secret)
+	if err != nil {
+		t.Fatalf("failed to split: %v", err)
+	}
+	words, err := encodeWords(blocks[2])
+	if err != nil {
+		t.Fatalf("failed to encode words: %v", err)
+	}
+	mistyped := append([]string{}, words...)
+	mistyped[0] = "abadnon"
+	var input bytes.Buffer
+	input.WriteString("\n" + layoutWords(mistyped) + "\n")
+	input.WriteString(layoutWords(words) + "\n")
+	input.Write(pem.EncodeToMemory(blocks[0]))
+	var output bytes.Buffer
+	collected, err := collectBlocks(&input, &output, nil)
+	if err != nil {
+		t.Fatalf("failed to collect blocks: %v", err)
+	}
+	if len(collected) != 2 || !strings.Contains(output.String(), "did you mean") {
+		t.Fatalf("got %v blocks with output:\n%s", len(collected), output.String())
+	}
+	combinedSecret, err := combineBackendDefault().combine(collected)
+	if err != nil {
+		t.Fatalf("failed to combine: %v", err)
+	}
+	if !bytes.Equal(combinedSecret, secret) {
+		t.Fatalf("got = %v, want = %v", combinedSecret, secret)
+	}
+}
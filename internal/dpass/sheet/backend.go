@@ -0,0 +1,237 @@
+package sheet
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rbee3u/dpass/internal/dpass/shamir"
+	"github.com/rbee3u/dpass/pkg/hashx"
+	"github.com/rbee3u/dpass/pkg/qrencode"
+	"github.com/spf13/cobra"
+	"rsc.io/qr"
+)
+
+const (
+	formatDefault = formatSVG
+	formatSVG     = "svg"
+	formatPDF     = "pdf"
+
+	paperDefault = paperA4
+	paperA4      = "a4"
+	paperLetter  = "letter"
+
+	outputDefault = ""
+	dateDefault   = ""
+	dateLayout    = time.DateOnly
+	fileMode      = 0o600
+
+	groupSize     = 4
+	checksumSize  = 2
+	cipherLineLen = 64
+)
+
+var (
+	errInvalidFormat = errors.New("invalid format")
+	errInvalidPaper  = errors.New("invalid paper")
+	errInvalidDate   = errors.New("invalid date")
+	errEmptyInput    = errors.New("empty input")
+	errDoesNotFit    = errors.New("content does not fit on one page")
+)
+
+type backend struct {
+	format string
+	paper  string
+	output string
+	date   string
+	now    func() time.Time
+}
+
+func backendDefault() *backend {
+	return &backend{
+		format: formatDefault,
+		paper:  paperDefault,
+		output: outputDefault,
+		date:   dateDefault,
+		now:    time.Now,
+	}
+}
+
+func NewCmd() *cobra.Command {
+	backend := backendDefault()
+	cmd := &cobra.Command{Use: "sheet [file]", Args: cobra.MaximumNArgs(1), RunE: backend.runE}
+	cmd.Flags().StringVarP(&backend.format, "format", "f", formatDefault, fmt.Sprintf(
+		"format of the page (%q | %q)", formatSVG, formatPDF))
+	cmd.Flags().StringVarP(&backend.paper, "paper", "p", paperDefault, fmt.Sprintf(
+		"size of the page (%q | %q)", paperA4, paperLetter))
+	cmd.Flags().StringVarP(&backend.output, "output", "o", outputDefault,
+		"path of output file, use standard output if empty")
+	cmd.Flags().StringVar(&backend.date, "date", dateDefault,
+		"creation date printed on the page like \"2006-01-02\", use today if empty")
+	return cmd
+}
+
+func (b *backend) runE(_ *cobra.Command, args []string) error {
+	var input []byte
+	var err error
+	if len(args) != 0 {
+		input, err = os.ReadFile(args[0])
+	} else {
+		input, err = io.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read input: %w", err)
+	}
+	page, err := b.render(input)
+	if err != nil {
+		return err
+	}
+	if len(b.output) == 0 {
+		_, err = os.Stdout.Write(page)
+	} else {
+		err = os.WriteFile(b.output, page, fileMode)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write page: %w", err)
+	}
+	return nil
+}
+
+func (b *backend) render(input []byte) ([]byte, error) {
+	width, height, err := b.paperSize()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check arguments: %w", err)
+	}
+	date := b.date
+	if len(date) == 0 {
+		date = b.now().Format(dateLayout)
+	} else if _, err := time.Parse(dateLayout, date); err != nil {
+		return nil, errInvalidDate
+	}
+	doc, err := parseDocument(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse input: %w", err)
+	}
+	doc.fields = append(doc.fields, field{"Created", date})
+	code, err := qrencode.Encode(doc.payload, qr.M)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode qr code: %w", err)
+	}
+	p, err := layout(width, height, doc, code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lay out page: %w", err)
+	}
+	switch b.format {
+	case formatSVG:
+		return p.svg(), nil
+	case formatPDF:
+		return p.pdf(), nil
+	default:
+		return nil, errInvalidFormat
+	}
+}
+
+// paperSize returns the size of the page in points.
+func (b *backend) paperSize() (float64, float64, error) {
+	switch b.paper {
+	case paperA4:
+		return 595, 842, nil
+	case paperLetter:
+		return 612, 792, nil
+	default:
+		return 0, 0, errInvalidPaper
+	}
+}
+
+type field struct {
+	label string
+	value string
+}
+
+// document is what a sheet shows: the payload goes into the QR code, and the
+// lines are the same payload for humans.
+type document struct {
+	title   string
+	fields  []field
+	payload string
+	lines   []string
+}
+
+// parseDocument accepts a PEM share, or anything else such as the output of
+// the encrypt command as a ciphertext.
+func parseDocument(input []byte) (*document, error) {
+	if block, _ := pem.Decode(input); block != nil {
+		return parseShare(block), nil
+	}
+	text := strings.Join(strings.Fields(string(input)), "")
+	if len(text) == 0 {
+		return nil, errEmptyInput
+	}
+	doc := &document{title: "Ciphertext", payload: text}
+	doc.fields = append(doc.fields, field{"Checksum", lineChecksum(text)})
+	for i := 0; i < len(text); i += cipherLineLen {
+		doc.lines = append(doc.lines, annotateLine(text[i:min(i+cipherLineLen, len(text))]))
+	}
+	return doc, nil
+}
+
+func parseShare(block *pem.Block) *document {
+	encoded := string(pem.EncodeToMemory(block))
+	doc := &document{title: "Share", payload: encoded}
+	headers := block.Headers
+	switch {
+	case block.Type == shamir.PolicyBlockType:
+		doc.title = "Policy Share"
+		doc.fields = append(doc.fields, field{"Set", headers[shamir.HeaderSet]},
+			field{"Holder", headers[shamir.HeaderHolder]}, field{"Path", headers[shamir.HeaderPath]})
+	case block.Type == shamir.BlockType:
+		doc.title = fmt.Sprintf("Share %v of %v", headers[shamir.HeaderIndex], headers[shamir.HeaderParts])
+		doc.fields = append(doc.fields,
+			field{"Set", headers[shamir.HeaderSet]},
+			field{"Index", headers[shamir.HeaderIndex]},
+			field{"N / M", fmt.Sprintf("%v / %v, %v needed to reconstruct",
+				headers[shamir.HeaderParts], headers[shamir.HeaderThreshold], headers[shamir.HeaderThreshold])})
+	}
+	if value, exist := headers[shamir.HeaderChecksum]; exist {
+		doc.fields = append(doc.fields, field{"Checksum", value})
+	}
+	if _, exist := headers[shamir.HeaderEnvelope]; exist {
+		doc.fields = append(doc.fields, field{"Encrypted", "yes, password of the holder needed"})
+	}
+	body := false
+	for line := range strings.Lines(encoded) {
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "-----BEGIN "):
+			body = len(headers) == 0
+		case strings.HasPrefix(line, "-----END "):
+			body = false
+		case body:
+			line = annotateLine(line)
+		case len(line) == 0:
+			body = true
+		}
+		doc.lines = append(doc.lines, line)
+	}
+	return doc
+}
+
+// annotateLine splits a line into groups and appends its checksum, so that
+// a line copied by hand can be verified on its own.
+func annotateLine(line string) string {
+	var groups []string
+	for i := 0; i < len(line); i += groupSize {
+		groups = append(groups, line[i:min(i+groupSize, len(line))])
+	}
+	return fmt.Sprintf("%-79s [%s]", strings.Join(groups, " "), lineChecksum(line))
+}
+
+func lineChecksum(line string) string {
+	return hex.EncodeToString(hashx.Sha256Sum(bytes.TrimSpace([]byte(line)))[:checksumSize])
+}
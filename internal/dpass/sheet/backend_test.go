@@ -0,0 +1,113 @@
+package sheet
+
+import (
+	"bytes"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/rbee3u/dpass/internal/dpass/shamir"
+)
+
+func TestBackendShare(t *testing.T) {
+	block := &pem.Block{
+		Type: shamir.BlockType,
+		Headers: map[string]string{
+			shamir.HeaderChecksum:  "1bb7f6a2",
+			shamir.HeaderIndex:     "1",
+			shamir.HeaderThreshold: "3",
+			shamir.HeaderParts:     "5",
+			shamir.HeaderSet:       "026217f06ddaf086",
+		},
+		Bytes: bytes.Repeat([]byte("To be, or not to be, that is the question."), 4),
+	}
+	b := backendDefault()
+	b.date = "2024-01-02"
+	page, err := b.render(pem.EncodeToMemory(block))
+	if err != nil {
+		t.Fatalf("failed to render: %v", err)
+	}
+	for _, want := range []string{"dpass Share 1 of 5", "026217f06ddaf086", "5 / 3, 3 needed",
+		"1bb7f6a2", "2024-01-02", "VG8g YmUs", "-----END SHAMIR-----", "Holder:"} {
+		if !bytes.Contains(page, []byte(want)) {
+			t.Fatalf("missing %q in page:\n%s", want, page)
+		}
+	}
+	if !regexp.MustCompile(` \[[0-9a-f]{4}\]</text>`).Match(page) {
+		t.Fatalf("missing checksum of lines in page:\n%s", page)
+	}
+
+	b.format = formatPDF
+	b.paper = paperLetter
+	page, err = b.render(pem.EncodeToMemory(block))
+	if err != nil {
+		t.Fatalf("failed to render: %v", err)
+	}
+	checkPDF(t, page)
+	if !bytes.Contains(page, []byte("/MediaBox [0 0 612 792]")) ||
+		!bytes.Contains(page, []byte("(dpass Share 1 of 5) Tj")) {
+		t.Fatalf("got page:\n%s", page)
+	}
+}
+
+func TestBackendCiphertext(t *testing.T) {
+	ciphertext := "63636336366331363830343989f0525931a606f3d22a1fb9248b2444e8a2db37cfe3\n"
+	b := backendDefault()
+	b.format = formatPDF
+	page, err := b.render([]byte(ciphertext))
+	if err != nil {
+		t.Fatalf("failed to render: %v", err)
+	}
+	checkPDF(t, page)
+	if !bytes.Contains(page, []byte("(dpass Ciphertext) Tj")) || !bytes.Contains(page, []byte("6363 6336")) {
+		t.Fatalf("got page:\n%s", page)
+	}
+
+	tests := []struct {
+		format string
+		paper  string
+		date   string
+		input  string
+		err    error
+	}{
+		{format: "png", paper: paperA4, input: ciphertext, err: errInvalidFormat},
+		{format: formatSVG, paper: "a3", input: ciphertext, err: errInvalidPaper},
+		{format: formatSVG, paper: paperA4, date: "01/02/2024", input: ciphertext, err: errInvalidDate},
+		{format: formatSVG, paper: paperA4, input: " \n", err: errEmptyInput},
+		{format: formatSVG, paper: paperA4, input: strings.Repeat("ab", 1000), err: errDoesNotFit},
+	}
+	for _, tt := range tests {
+		b := backendDefault()
+		b.format, b.paper, b.date = tt.format, tt.paper, tt.date
+		if _, err := b.render([]byte(tt.input)); !errors.Is(err, tt.err) {
+			t.Errorf("got = %v, want = %v", err, tt.err)
+		}
+	}
+}
+
+// checkPDF checks that the cross-reference table points at the objects.
+func checkPDF(t *testing.T, page []byte) {
+	t.Helper()
+	if !bytes.HasPrefix(page, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(page, []byte("%%EOF\n")) {
+		t.Fatalf("invalid envelope of pdf")
+	}
+	matches := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(page)
+	if matches == nil {
+		t.Fatalf("missing startxref")
+	}
+	xref, _ := strconv.Atoi(string(matches[1]))
+	if !bytes.HasPrefix(page[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref points at %q", page[xref:min(xref+10, len(page))])
+	}
+	offsets := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(page[xref:], -1)
+	for i, offset := range offsets {
+		position, _ := strconv.Atoi(string(offset[1]))
+		if want := fmt.Sprintf("%v 0 obj\n", i+1); !bytes.HasPrefix(page[position:], []byte(want)) {
+			t.Fatalf("object %v points at %q", i+1, page[position:min(position+10, len(page))])
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package sheet
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"math"
+	"strconv"
+	"strings"
+
+	"rsc.io/qr"
+)
+
+const (
+	margin      = 50.0
+	qrSize      = 200.0
+	qrQuiet     = 4
+	titleSize   = 18.0
+	fieldSize   = 11.0
+	fieldLead   = 16.0
+	textSize    = 8.0
+	textLead    = 10.5
+	noteLead    = 26.0
+	noteLines   = 4
+	monoAdvance = 0.6
+
+	fontSans = "Helvetica"
+	fontBold = "Helvetica-Bold"
+	fontMono = "Courier"
+)
+
+// Coordinates of the page are in points from the top left corner, the PDF
+// renderer flips them since PDF starts from the bottom left corner.
+type rect struct{ x, y, w, h float64 }
+
+type line struct{ x1, y1, x2, y2 float64 }
+
+type text struct {
+	x, y  float64
+	font  string
+	size  float64
+	value string
+}
+
+type page struct {
+	width, height float64
+	rects         []rect
+	lines         []line
+	texts         []text
+}
+
+func layout(width, height float64, doc *document, code *qr.Code) (*page, error) {
+	p := &page{width: width, height: height}
+	// The quiet zone is left blank, only the dark modules are drawn, merging
+	// the runs of each row to keep the output small.
+	module := qrSize / float64(code.Size+2*qrQuiet)
+	qrX, qrY := width-margin-qrSize, margin
+	for y := range code.Size {
+		for x := 0; x < code.Size; x++ {
+			start := x
+			for x < code.Size && code.Black(x, y) {
+				x++
+			}
+			if x > start {
+				p.rects = append(p.rects, rect{
+					x: qrX + float64(qrQuiet+start)*module,
+					y: qrY + float64(qrQuiet+y)*module,
+					w: float64(x-start) * module,
+					h: module,
+				})
+			}
+		}
+	}
+
+	y := margin + titleSize
+	p.texts = append(p.texts, text{margin, y, fontBold, titleSize, "dpass " + doc.title})
+	y += fieldLead
+	for _, f := range doc.fields {
+		y += fieldLead
+		p.texts = append(p.texts,
+			text{margin, y, fontBold, fieldSize, f.label + ":"},
+			text{margin + 70, y, fontSans, fieldSize, f.value})
+	}
+
+	y = max(y, qrY+qrSize) + 2*fieldLead
+	p.texts = append(p.texts, text{margin, y, fontBold, fieldSize,
+		"Text (each data line ends with its checksum in brackets)"})
+	y += fieldLead / 2
+	for _, value := range doc.lines {
+		y += textLead
+		if margin+float64(len(value))*textSize*monoAdvance > width-margin {
+			return nil, errDoesNotFit
+		}
+		p.texts = append(p.texts, text{margin, y, fontMono, textSize, value})
+	}
+
+	y += fieldLead
+	for _, label := range []string{"Holder", "Location", "Notes"} {
+		y += noteLead
+		p.texts = append(p.texts, text{margin, y, fontBold, fieldSize, label + ":"})
+		p.lines = append(p.lines, line{margin + 70, y + 2, width - margin, y + 2})
+	}
+	for range noteLines - 1 {
+		y += noteLead
+		p.lines = append(p.lines, line{margin, y + 2, width - margin, y + 2})
+	}
+	if y > height-margin {
+		return nil, errDoesNotFit
+	}
+	return p, nil
+}
+
+func (p *page) svg() []byte {
+	var buf bytes.Buffer
+	_, _ = fmt.Fprintf(&buf, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n"+
+		"<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%vpt\" height=\"%vpt\" viewBox=\"0 0 %v %v\">\n",
+		num(p.width), num(p.height), num(p.width), num(p.height))
+	_, _ = fmt.Fprintf(&buf, "<rect width=\"%v\" height=\"%v\" fill=\"#fff\"/>\n", num(p.width), num(p.height))
+	for _, r := range p.rects {
+		_, _ = fmt.Fprintf(&buf, "<rect x=\"%v\" y=\"%v\" width=\"%v\" height=\"%v\"/>\n",
+			num(r.x), num(r.y), num(r.w), num(r.h))
+	}
+	for _, l := range p.lines {
+		_, _ = fmt.Fprintf(&buf, "<line x1=\"%v\" y1=\"%v\" x2=\"%v\" y2=\"%v\" stroke=\"#000\" stroke-width=\"0.5\"/>\n",
+			num(l.x1), num(l.y1), num(l.x2), num(l.y2))
+	}
+	for _, t := range p.texts {
+		family, weight := "Helvetica, Arial, sans-serif", "normal"
+		switch t.font {
+		case fontBold:
+			weight = "bold"
+		case fontMono:
+			family = "Courier, monospace"
+		}
+		_, _ = fmt.Fprintf(&buf, "<text x=\"%v\" y=\"%v\" font-family=\"%s\" font-weight=\"%s\" font-size=\"%v\" "+
+			"xml:space=\"preserve\">%s</text>\n", num(t.x), num(t.y), family, weight, num(t.size),
+			html.EscapeString(t.value))
+	}
+	buf.WriteString("</svg>\n")
+	return buf.Bytes()
+}
+
+// pdf writes a single page PDF by hand, with the standard fonts every reader
+// provides so that nothing needs to be embedded.
+func (p *page) pdf() []byte {
+	var content bytes.Buffer
+	for _, r := range p.rects {
+		_, _ = fmt.Fprintf(&content, "%v %v %v %v re f\n", num(r.x), num(p.height-r.y-r.h), num(r.w), num(r.h))
+	}
+	content.WriteString("0.5 w\n")
+	for _, l := range p.lines {
+		_, _ = fmt.Fprintf(&content, "%v %v m %v %v l S\n", num(l.x1), num(p.height-l.y1), num(l.x2), num(p.height-l.y2))
+	}
+	fonts := map[string]string{fontSans: "F1", fontBold: "F2", fontMono: "F3"}
+	for _, t := range p.texts {
+		_, _ = fmt.Fprintf(&content, "BT /%s %v Tf %v %v Td (%s) Tj ET\n",
+			fonts[t.font], num(t.size), num(t.x), num(p.height-t.y), pdfString(t.value))
+	}
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %v %v] /Contents 4 0 R "+
+			"/Resources << /Font << /F1 5 0 R /F2 6 0 R /F3 7 0 R >> >> >>", num(p.width), num(p.height)),
+		fmt.Sprintf("<< /Length %v >>\nstream\n%sendstream", content.Len(), content.String()),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /" + fontSans + " /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /" + fontBold + " /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /" + fontMono + " /Encoding /WinAnsiEncoding >>",
+	}
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		_, _ = fmt.Fprintf(&buf, "%v 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := buf.Len()
+	_, _ = fmt.Fprintf(&buf, "xref\n0 %v\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		_, _ = fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	_, _ = fmt.Fprintf(&buf, "trailer\n<< /Size %v /Root 1 0 R >>\nstartxref\n%v\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+// pdfString escapes a literal string, characters outside of printable ASCII
+// are replaced since the standard fonts are not embedded.
+func pdfString(value string) string {
+	var builder strings.Builder
+	for _, r := range value {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			builder.WriteByte('\\')
+			builder.WriteRune(r)
+		case r < ' ' || r > '~':
+			builder.WriteByte('?')
+		default:
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
+
+func num(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
@@ -2,9 +2,17 @@ package bech32
 
 import (
 	"bytes"
+	"strings"
 )
 
-const alphabet = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+const (
+	alphabet = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+	// constBech32m is what the checksum of bech32m yields instead of 1, it is
+	// used by addresses of witness version 1 and above.
+	constBech32m = 0x2bc830a3
+	checksumLen  = 6
+)
 
 func Encode(hrp string, vs, in []byte) string {
 	vsin, remain, shift := bytes.Clone(vs), uint32(0), 0
@@ -27,6 +35,43 @@ func Encode(hrp string, vs, in []byte) string {
 		data = append(data, alphabet[vsin[i]])
 	}
 
+	polymod := checksum(hrp, append(vsin, 0, 0, 0, 0, 0, 0))
+	return string(append(data,
+		alphabet[(polymod>>25)&31], alphabet[(polymod>>20)&31], alphabet[(polymod>>15)&31],
+		alphabet[(polymod>>10)&31], alphabet[(polymod>>5)&31], alphabet[(polymod^1)&31],
+	))
+}
+
+// Valid tells whether s is a bech32 or bech32m string with a valid checksum,
+// either all in lower case or all in upper case.
+func Valid(s string) bool {
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return false
+	}
+	s = strings.ToLower(s)
+	pos := strings.LastIndexByte(s, '1')
+	if pos < 1 || len(s)-pos-1 < checksumLen {
+		return false
+	}
+	for i := range pos {
+		if s[i] < 33 || s[i] > 126 {
+			return false
+		}
+	}
+	data := make([]byte, 0, len(s)-pos-1)
+	for i := pos + 1; i < len(s); i++ {
+		value := strings.IndexByte(alphabet, s[i])
+		if value < 0 {
+			return false
+		}
+		data = append(data, byte(value))
+	}
+	polymod := checksum(s[:pos], data)
+	return polymod == 1 || polymod == constBech32m
+}
+
+// checksum computes the BCH code over the expanded hrp and the values.
+func checksum(hrp string, values []byte) uint32 {
 	polymod := uint32(1)
 	iterate := func(value uint32) {
 		polymod, value = ((polymod&0x1ffffff)<<5)^value, polymod
@@ -43,15 +88,8 @@ func Encode(hrp string, vs, in []byte) string {
 	for i := range len(hrp) {
 		iterate(uint32(hrp[i] & 31))
 	}
-	for i := range vsin {
-		iterate(uint32(vsin[i]))
-	}
-	for range 6 {
-		iterate(0)
+	for _, value := range values {
+		iterate(uint32(value))
 	}
-
-	return string(append(data,
-		alphabet[(polymod>>25)&31], alphabet[(polymod>>20)&31], alphabet[(polymod>>15)&31],
-		alphabet[(polymod>>10)&31], alphabet[(polymod>>5)&31], alphabet[(polymod^1)&31],
-	))
+	return polymod
 }
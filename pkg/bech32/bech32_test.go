@@ -66,3 +66,24 @@ func TestEncode(t *testing.T) {
 		}
 	}
 }
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		s     string
+		valid bool
+	}{
+		{s: "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", valid: true},
+		{s: "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", valid: true},
+		{s: "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", valid: true},
+		{s: "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5", valid: false},
+		{s: "bc1qw508d6qejxtdg4y5r3zarvARY0c5xw7kv8f3t4", valid: false},
+		{s: "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kb8f3t4", valid: false},
+		{s: "1qw508d6", valid: false},
+		{s: "bc1", valid: false},
+	}
+	for _, tt := range tests {
+		if got := bech32.Valid(tt.s); got != tt.valid {
+			t.Errorf("%s: got = %v, want = %v", tt.s, got, tt.valid)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"math/big"
 
+	"github.com/rbee3u/dpass/pkg/hashx"
 	"github.com/rbee3u/dpass/pkg/secp256k1"
 )
 
@@ -45,6 +46,18 @@ func Secp256k1DeriveSk(seed []byte, path []uint32) ([]byte, error) {
 	return sk, nil
 }
 
+// Secp256k1Fingerprint returns the fingerprint of the master key, which is
+// what wallets show to tell seeds and passphrases apart.
+func Secp256k1Fingerprint(seed []byte) []byte {
+	sk, _ := calculateHmacSha512([]byte("Bitcoin seed"), seed)
+	secp256k1AssertSk(sk)
+	x, y := secp256k1.S256().ScalarBaseMult(sk)
+	pk := make([]byte, 33)
+	pk[0] = 2 + byte(y.Bit(0))
+	x.FillBytes(pk[1:])
+	return hashx.RipeMD160Sum(hashx.Sha256Sum(pk))[:4]
+}
+
 func secp256k1AssertSk(sk []byte) {
 	if zero := [32]byte{}; bytes.Equal(sk, zero[:]) {
 		panic("bip32: secp256k1: sk is too small")
@@ -220,3 +220,28 @@ func TestEd25519DeriveSk(t *testing.T) {
 		}
 	}
 }
+
+func TestSecp256k1Fingerprint(t *testing.T) {
+	tests := []struct {
+		seed          string
+		fingerprint0x string
+	}{
+		{
+			seed:          "000102030405060708090a0b0c0d0e0f",
+			fingerprint0x: "3442193e",
+		},
+		{
+			seed:          "fffcf9f6f3f0edeae7e4e1dedbd8d5d2cfccc9c6c3c0bdbab7b4b1aeaba8a5a29f9c999693908d8a8784817e7b7875726f6c696663605d5a5754514e4b484542",
+			fingerprint0x: "bd16bee5",
+		},
+	}
+	for _, tt := range tests {
+		seed, err := hex.DecodeString(tt.seed)
+		if err != nil {
+			t.Fatalf("failed to decode seed: %v", err)
+		}
+		if fingerprint0x := hex.EncodeToString(bip3x.Secp256k1Fingerprint(seed)); fingerprint0x != tt.fingerprint0x {
+			t.Errorf("got = %s, want = %s", fingerprint0x, tt.fingerprint0x)
+		}
+	}
+}
@@ -3,7 +3,6 @@ package bip3x
 import (
 	"crypto/rand"
 	"crypto/sha512"
-	_ "embed"
 	"fmt"
 	"strings"
 
@@ -22,6 +21,9 @@ const (
 	SentenceBitsStep = 33
 	SentenceBitsMin  = 4 * SentenceBitsStep
 	SentenceBitsMax  = 8 * SentenceBitsStep
+
+	// PrefixLength is the number of leading letters that identify a word uniquely.
+	PrefixLength = 4
 )
 
 type InvalidEntropyBitsError struct{ v int }
@@ -49,30 +51,33 @@ func (e DigestUnmatchedError) Error() string {
 }
 
 var (
-	//go:embed english.txt
-	english    string
-	value2word = strings.Fields(english)
-	word2value = generateWord2Value()
-)
+	value2word = wordlists[LanguageEnglish].words
+	word2value = wordlists[LanguageEnglish].values
 
-func generateWord2Value() map[string]uint32 {
-	word2value := make(map[string]uint32)
-	for value, word := range value2word {
-		word2value[word] = uint32(value)
-	}
-	return word2value
-}
+	prefix2value = generatePrefix2Value()
+)
 
 func CreateEntropyRandomly(entropySize int) ([]byte, error) {
 	if entropySize%EntropyBitsStep != 0 || entropySize < EntropyBitsMin || entropySize > EntropyBitsMax {
 		return nil, InvalidEntropyBitsError{v: entropySize}
 	}
 	entropy := make([]byte, entropySize/BitsPerByte)
-	_, _ = rand.Read(entropy)
+	if _, err := rand.Read(entropy); err != nil {
+		return nil, fmt.Errorf("bip39: failed to read random: %w", err)
+	}
 	return entropy, nil
 }
 
 func EntropyToMnemonic(entropy []byte) (string, error) {
+	return EntropyToMnemonicIn(entropy, LanguageEnglish)
+}
+
+// EntropyToMnemonicIn is EntropyToMnemonic with the words of a language.
+func EntropyToMnemonicIn(entropy []byte, language string) (string, error) {
+	list, exist := wordlists[language]
+	if !exist {
+		return "", UnsupportedLanguageError{v: language}
+	}
 	entropyBits := len(entropy) * BitsPerByte
 	if entropyBits%EntropyBitsStep != 0 || entropyBits < EntropyBitsMin || entropyBits > EntropyBitsMax {
 		return "", InvalidEntropyBitsError{v: entropyBits}
@@ -83,38 +88,173 @@ func EntropyToMnemonic(entropy []byte) (string, error) {
 	for i := range entropy {
 		remain, shift = (remain<<BitsPerByte)|uint32(entropy[i]), shift+BitsPerByte
 		for reducedShift := shift - BitsPerWord; reducedShift >= 0; reducedShift = shift - BitsPerWord {
-			sentence = append(sentence, value2word[remain>>reducedShift])
+			sentence = append(sentence, list.words[remain>>reducedShift])
 			remain, shift = remain&((1<<reducedShift)-1), reducedShift
 		}
 	}
 	digest := uint32(hashx.Sha256Sum(entropy)[0] >> (BitsPerByte - digestBits))
-	sentence = append(sentence, value2word[(remain<<digestBits)|digest])
-	return strings.Join(sentence, " "), nil
+	sentence = append(sentence, list.words[(remain<<digestBits)|digest])
+	return strings.Join(sentence, separator(language)), nil
 }
 
+// MnemonicToSeed accepts mnemonics in any of the languages, both of them
+// and the password are normalized with NFKD.
 func MnemonicToSeed(mnemonic string, password string) ([]byte, error) {
-	sentence := strings.Fields(mnemonic)
-	sentenceBits := len(sentence) * BitsPerWord
+	if _, err := MnemonicToEntropy(mnemonic); err != nil {
+		return nil, err
+	}
+	salt := append([]byte("mnemonic"), normalize(password)...)
+	sentence := strings.Join(strings.Fields(normalize(mnemonic)), " ")
+	return pbkdf2.Key([]byte(sentence), salt, 2048, 64, sha512.New), nil
+}
+
+// MnemonicToEntropy is the reverse of EntropyToMnemonic, the digest in the
+// last word is checked. The language is detected, words shared by several
+// lists are resolved by the digest.
+func MnemonicToEntropy(mnemonic string) ([]byte, error) {
+	detected, err := DetectLanguage(mnemonic)
+	if err != nil {
+		return nil, err
+	}
+	sentence := strings.Fields(normalize(mnemonic))
+	var firstErr error
+	for _, language := range detected {
+		entropy, err := sentenceToEntropy(sentence, wordlists[language])
+		if err == nil {
+			return entropy, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return nil, firstErr
+}
+
+func sentenceToEntropy(sentence []string, list *wordlist) ([]byte, error) {
+	values := make([]uint32, len(sentence))
+	for i, word := range sentence {
+		value, exist := list.values[word]
+		if !exist {
+			return nil, WordNotExistError{v: word}
+		}
+		values[i] = value
+	}
+	return valuesToEntropy(values)
+}
+
+// valuesToEntropy is the reverse of the bit packing of EntropyToMnemonic on
+// the values of the words, the digest in the last word is checked.
+func valuesToEntropy(values []uint32) ([]byte, error) {
+	entropy, remain, digestBits, err := packValues(values)
+	if err != nil {
+		return nil, err
+	}
+	if digest := uint32(hashx.Sha256Sum(entropy)[0] >> (BitsPerByte - digestBits)); remain != digest {
+		return nil, DigestUnmatchedError{v: remain, u: digest}
+	}
+	return entropy, nil
+}
+
+// packValues packs the values of the words into the entropy, and returns the
+// remaining bits of the digest with their number.
+func packValues(values []uint32) ([]byte, uint32, int, error) {
+	sentenceBits := len(values) * BitsPerWord
 	if sentenceBits%SentenceBitsStep != 0 || sentenceBits < SentenceBitsMin || sentenceBits > SentenceBitsMax {
-		return nil, InvalidSentenceBitsError{v: sentenceBits}
+		return nil, 0, 0, InvalidSentenceBitsError{v: sentenceBits}
 	}
 	digestBits := sentenceBits / SentenceBitsStep
 	entropy := make([]byte, 0, digestBits*EntropyBitsStep/BitsPerByte)
 	remain, shift := uint32(0), 0
-	for _, word := range sentence {
-		value, exist := word2value[word]
-		if !exist {
-			return nil, WordNotExistError{v: word}
-		}
+	for _, value := range values {
 		remain, shift = (remain<<BitsPerWord)|value, shift+BitsPerWord
 		for reducedShift := shift - BitsPerByte; reducedShift > 0; reducedShift = shift - BitsPerByte {
 			entropy = append(entropy, byte(remain>>reducedShift))
 			remain, shift = remain&((1<<reducedShift)-1), reducedShift
 		}
 	}
-	if digest := uint32(hashx.Sha256Sum(entropy)[0] >> (BitsPerByte - digestBits)); remain != digest {
-		return nil, DigestUnmatchedError{v: remain, u: digest}
+	return entropy, remain, digestBits, nil
+}
+
+// ValueToWord returns the word of an 11-bit value.
+func ValueToWord(value uint32) string {
+	return value2word[value&(1<<BitsPerWord-1)]
+}
+
+// WordToValue returns the value of a word, a word may be abbreviated to its
+// first PrefixLength letters as when engraved on steel.
+func WordToValue(word string) (uint32, error) {
+	word = strings.ToLower(word)
+	if value, exist := word2value[word]; exist {
+		return value, nil
+	}
+	if len(word) >= PrefixLength {
+		if value, exist := prefix2value[word[:PrefixLength]]; exist && strings.HasPrefix(value2word[value], word) {
+			return value, nil
+		}
+	}
+	return 0, WordNotExistError{v: word}
+}
+
+// wordToValueIn returns the value of a normalized word of a language, English
+// words may be abbreviated as with WordToValue.
+func wordToValueIn(word string, language string) (uint32, error) {
+	if value, exist := wordlists[language].values[word]; exist {
+		return value, nil
+	}
+	if language == LanguageEnglish {
+		return WordToValue(word)
+	}
+	return 0, WordNotExistError{v: word}
+}
+
+// SuggestWord returns the word closest to a mistyped one by edit distance.
+func SuggestWord(word string) string {
+	return value2word[closestValue(strings.ToLower(word), value2word)]
+}
+
+// closestValue returns the value of the key closest to the word by edit distance.
+func closestValue(word string, keys []string) uint32 {
+	best, bestDistance := 0, -1
+	for value, key := range keys {
+		if distance := editDistance(word, key); bestDistance < 0 || distance < bestDistance {
+			best, bestDistance = value, distance
+		}
+	}
+	return uint32(best)
+}
+
+func generatePrefix2Value() map[string]uint32 {
+	prefix2value := make(map[string]uint32)
+	for value, word := range value2word {
+		prefix2value[word[:min(len(word), PrefixLength)]] = uint32(value)
+	}
+	return prefix2value
+}
+
+// editDistance is the Damerau-Levenshtein distance restricted to adjacent
+// transpositions, which are the most common typos. It counts letters rather
+// than bytes so that accented or CJK words are not penalized.
+func editDistance(x, y string) int {
+	a, b := []rune(x), []rune(y)
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, curr = prev, curr, prev2
 	}
-	salt := append([]byte("mnemonic"), password...)
-	return pbkdf2.Key([]byte(strings.Join(sentence, " ")), salt, 2048, 64, sha512.New), nil
+	return prev[len(b)]
 }
@@ -4,9 +4,16 @@ import (
 	"bytes"
 	"compress/flate"
 	"encoding/hex"
+	"errors"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/rbee3u/dpass/pkg/bip3x"
+	"golang.org/x/text/unicode/norm"
 )
 
 func TestCreateEntropyRandomly(t *testing.T) {
@@ -147,6 +154,13 @@ func TestEntropyToMnemonic(t *testing.T) {
 		if mnemonic != tt.mnemonic {
 			t.Errorf("got = %s, want = %s", mnemonic, tt.mnemonic)
 		}
+		reversed, err := bip3x.MnemonicToEntropy(tt.mnemonic)
+		if err != nil {
+			t.Fatalf("failed to convert mnemonic to entropy: %v", err)
+		}
+		if !bytes.Equal(reversed, entropy) {
+			t.Errorf("got = %x, want = %x", reversed, entropy)
+		}
 	}
 }
 
@@ -262,3 +276,353 @@ func TestMnemonicToSeed(t *testing.T) {
 		}
 	}
 }
+
+func TestWordToValue(t *testing.T) {
+	tests := []struct {
+		word  string
+		value uint32
+		full  string
+	}{
+		{word: "abandon", value: 0, full: "abandon"},
+		{word: "aban", value: 0, full: "abandon"},
+		{word: "abando", value: 0, full: "abandon"},
+		{word: "ZOO", value: 2047, full: "zoo"},
+		{word: "vict", value: 1949, full: "victory"},
+	}
+	for _, tt := range tests {
+		value, err := bip3x.WordToValue(tt.word)
+		if err != nil {
+			t.Fatalf("failed to convert word to value: %v", err)
+		}
+		if value != tt.value {
+			t.Errorf("got = %v, want = %v", value, tt.value)
+		}
+		if word := bip3x.ValueToWord(value); word != tt.full {
+			t.Errorf("got = %v, want = %v", word, tt.full)
+		}
+	}
+	for _, word := range []string{"aba", "abandonx", "abn", "xyzw"} {
+		if _, err := bip3x.WordToValue(word); err == nil {
+			t.Errorf("expect error with word %q", word)
+		}
+	}
+}
+
+func TestSuggestWord(t *testing.T) {
+	tests := []struct {
+		word       string
+		suggestion string
+	}{
+		{word: "abadnon", suggestion: "abandon"},
+		{word: "zoa", suggestion: "zoo"},
+		{word: "gossop", suggestion: "gossip"},
+	}
+	for _, tt := range tests {
+		if suggestion := bip3x.SuggestWord(tt.word); suggestion != tt.suggestion {
+			t.Errorf("got = %v, want = %v", suggestion, tt.suggestion)
+		}
+	}
+}
+
+func TestMnemonicNormalization(t *testing.T) {
+	mnemonic := "legal winner thank year wave sausage worth useful legal winner thank yellow"
+	want, err := bip3x.MnemonicToSeed(mnemonic, "café")
+	if err != nil {
+		t.Fatalf("failed to convert mnemonic to seed: %v", err)
+	}
+	for _, variant := range []struct{ mnemonic, password string }{
+		{mnemonic, "café"},
+		{strings.ReplaceAll(mnemonic, " ", "\u3000"), "cafe\u0301"},
+		{" " + strings.ReplaceAll(mnemonic, " ", "  ") + "\n", "café"},
+	} {
+		got, err := bip3x.MnemonicToSeed(variant.mnemonic, variant.password)
+		if err != nil {
+			t.Fatalf("failed to convert mnemonic to seed: %v", err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("got = %x, want = %x", got, want)
+		}
+	}
+}
+
+func TestLanguages(t *testing.T) {
+	for _, language := range bip3x.Languages() {
+		entropy := bytes.Repeat([]byte{0x7f}, 16)
+		mnemonic, err := bip3x.EntropyToMnemonicIn(entropy, language)
+		if err != nil {
+			t.Fatalf("failed to convert entropy to mnemonic: %v", err)
+		}
+		detected, err := bip3x.DetectLanguage(mnemonic)
+		if err != nil {
+			t.Fatalf("failed to detect language: %v", err)
+		}
+		if !slices.Contains(detected, language) {
+			t.Errorf("got = %v, want = %v", detected, language)
+		}
+		got, err := bip3x.MnemonicToEntropy(mnemonic)
+		if err != nil {
+			t.Fatalf("failed to convert mnemonic to entropy: %v", err)
+		}
+		if !bytes.Equal(got, entropy) {
+			t.Errorf("got = %x, want = %x", got, entropy)
+		}
+	}
+	if _, err := bip3x.EntropyToMnemonicIn(make([]byte, 16), "klingon"); !errors.As(err, &bip3x.UnsupportedLanguageError{}) {
+		t.Errorf("got = %v, want = %v", err, bip3x.UnsupportedLanguageError{})
+	}
+}
+
+func TestCreateEntropyFromRolls(t *testing.T) {
+	tests := []struct {
+		rolls     string
+		sides     int
+		entropy0x string
+		bits      int
+	}{
+		{
+			rolls:     strings.Repeat("H", 128),
+			sides:     bip3x.CoinSides,
+			entropy0x: "ffffffffffffffffffffffffffffffff",
+			bits:      128,
+		},
+		{
+			rolls:     "HTht 10",
+			sides:     bip3x.CoinSides,
+			entropy0x: "a8000000000000000000000000000000",
+			bits:      6,
+		},
+		{
+			rolls:     "1234, 56",
+			sides:     bip3x.DiceSides,
+			entropy0x: "1b400000000000000000000000000000",
+			bits:      10,
+		},
+		{
+			rolls:     strings.Repeat("4", 70),
+			sides:     bip3x.DiceSides,
+			entropy0x: "ffffffffffffffffffffffffffffffff",
+			bits:      128,
+		},
+	}
+	for _, tt := range tests {
+		entropy, bits, err := bip3x.CreateEntropyFromRolls(tt.rolls, tt.sides, bip3x.EntropyBitsMin)
+		if err != nil {
+			t.Fatalf("failed to create entropy from rolls: %v", err)
+		}
+		if entropy0x := hex.EncodeToString(entropy); entropy0x != tt.entropy0x || bits != tt.bits {
+			t.Errorf("got = %s(%v), want = %s(%v)", entropy0x, bits, tt.entropy0x, tt.bits)
+		}
+	}
+	for _, rolls := range []string{"1237", "0", "HX"} {
+		sides := bip3x.DiceSides
+		if strings.HasPrefix(rolls, "H") {
+			sides = bip3x.CoinSides
+		}
+		if _, _, err := bip3x.CreateEntropyFromRolls(rolls, sides, bip3x.EntropyBitsMin); !errors.As(err, &bip3x.InvalidRollError{}) {
+			t.Errorf("got = %v, want = %v", err, bip3x.InvalidRollError{})
+		}
+	}
+}
+
+func TestRepairMnemonic(t *testing.T) {
+	mnemonic := "legal winner thank year wave sausage worth useful legal winner thank yellow"
+	tests := []struct {
+		damaged  string
+		distance int
+	}{
+		{damaged: "legal winner thank year wave ? worth useful legal winner thank yellow", distance: 0},
+		{damaged: "legal winner thank year wave sausage worth useful legal winner thank ?", distance: 0},
+		{damaged: "legal winnre thank year wave sausage worth useful legal winner thnak yellow", distance: 2},
+		{damaged: "legal winner thank year sausage wave worth useful legal winner thank yellow", distance: 1},
+		{damaged: "legal winner thank year wave sausage worth useful legal winner thank yelow", distance: 1},
+	}
+	for _, tt := range tests {
+		repairs, err := bip3x.RepairMnemonic(tt.damaged, nil)
+		if err != nil {
+			t.Fatalf("failed to repair mnemonic: %v", err)
+		}
+		index := slices.IndexFunc(repairs, func(r bip3x.Repair) bool { return r.Mnemonic == mnemonic })
+		if index < 0 || repairs[index].Distance != tt.distance {
+			t.Fatalf("got = %v, want = %v", repairs, mnemonic)
+		}
+		for _, repair := range repairs {
+			if _, err := bip3x.MnemonicToEntropy(repair.Mnemonic); err != nil {
+				t.Errorf("failed to convert mnemonic to entropy: %v", err)
+			}
+		}
+	}
+
+	repairs, err := bip3x.RepairMnemonic("legal ? thank year wave sausage worth useful legal winner thank ?",
+		func(candidate string) bool { return candidate == mnemonic })
+	if err != nil {
+		t.Fatalf("failed to repair mnemonic: %v", err)
+	}
+	if len(repairs) != 1 || repairs[0].Mnemonic != mnemonic {
+		t.Errorf("got = %v, want = %v", repairs, mnemonic)
+	}
+
+	if _, err := bip3x.RepairMnemonic("? ? ? year wave sausage worth useful legal winner thank yellow", nil); !errors.As(
+		err, &bip3x.TooManyCombinationsError{}) {
+		t.Errorf("got = %v, want = %v", err, bip3x.TooManyCombinationsError{})
+	}
+
+	entropy := bytes.Repeat([]byte{0x7f}, 16)
+	for _, language := range []string{bip3x.LanguageSpanish, bip3x.LanguageJapanese} {
+		mnemonic, err := bip3x.EntropyToMnemonicIn(entropy, language)
+		if err != nil {
+			t.Fatalf("failed to convert entropy to mnemonic: %v", err)
+		}
+		sentence := strings.Fields(mnemonic)
+		sentence[len(sentence)-1] = bip3x.UnknownWord
+		repairs, err := bip3x.RepairMnemonicIn(strings.Join(sentence, " "), language, nil)
+		if err != nil {
+			t.Fatalf("failed to repair mnemonic: %v", err)
+		}
+		if !slices.ContainsFunc(repairs, func(r bip3x.Repair) bool { return r.Mnemonic == mnemonic }) {
+			t.Errorf("got = %v, want = %v", repairs, mnemonic)
+		}
+	}
+	if _, err := bip3x.RepairMnemonicIn(mnemonic, "klingon", nil); !errors.As(err, &bip3x.UnsupportedLanguageError{}) {
+		t.Errorf("got = %v, want = %v", err, bip3x.UnsupportedLanguageError{})
+	}
+}
+
+func TestLastWords(t *testing.T) {
+	tests := []struct {
+		mnemonic string
+		count    int
+		want     string
+	}{
+		{
+			mnemonic: "legal winner thank year wave sausage worth useful legal winner thank",
+			count:    128,
+			want:     "yellow",
+		},
+		{
+			mnemonic: strings.Repeat("abandon ", 23),
+			count:    8,
+			want:     "art",
+		},
+	}
+	for _, tt := range tests {
+		lastWords, err := bip3x.LastWords(tt.mnemonic)
+		if err != nil {
+			t.Fatalf("failed to get last words: %v", err)
+		}
+		if len(lastWords) != tt.count {
+			t.Fatalf("got = %v, want = %v", len(lastWords), tt.count)
+		}
+		found := false
+		for _, lastWord := range lastWords {
+			if _, err := bip3x.MnemonicToEntropy(tt.mnemonic + " " + lastWord.Word); err != nil {
+				t.Errorf("failed to convert mnemonic to entropy: %v", err)
+			}
+			found = found || lastWord.Word == tt.want
+		}
+		if !found {
+			t.Errorf("got = %v, want = %v", lastWords, tt.want)
+		}
+	}
+	if _, err := bip3x.LastWords("legal winner thank"); !errors.As(err, &bip3x.InvalidSentenceBitsError{}) {
+		t.Errorf("got = %v, want = %v", err, bip3x.InvalidSentenceBitsError{})
+	}
+
+	entropy := bytes.Repeat([]byte{0x7f}, 16)
+	for _, language := range []string{bip3x.LanguageSpanish, bip3x.LanguageJapanese} {
+		mnemonic, err := bip3x.EntropyToMnemonicIn(entropy, language)
+		if err != nil {
+			t.Fatalf("failed to convert entropy to mnemonic: %v", err)
+		}
+		sentence := strings.Fields(mnemonic)
+		lastWords, err := bip3x.LastWordsIn(strings.Join(sentence[:len(sentence)-1], " "), language)
+		if err != nil {
+			t.Fatalf("failed to get last words: %v", err)
+		}
+		if !slices.ContainsFunc(lastWords, func(w bip3x.LastWord) bool { return w.Word == sentence[len(sentence)-1] }) {
+			t.Errorf("got = %v, want = %v", lastWords, sentence[len(sentence)-1])
+		}
+	}
+	if _, err := bip3x.LastWordsIn("abandon", "klingon"); !errors.As(err, &bip3x.UnsupportedLanguageError{}) {
+		t.Errorf("got = %v, want = %v", err, bip3x.UnsupportedLanguageError{})
+	}
+}
+
+func TestWordlists(t *testing.T) {
+	// Checksums of the files in https://github.com/bitcoin/bips/tree/master/bip-0039.
+	checksums := map[string]uint32{
+		bip3x.LanguageEnglish:            0xc1dbd296,
+		bip3x.LanguageChineseSimplified:  0xe3721bbf,
+		bip3x.LanguageChineseTraditional: 0x3c20b443,
+		bip3x.LanguageCzech:              0xd1b5fda0,
+		bip3x.LanguageFrench:             0x3e56b216,
+		bip3x.LanguageItalian:            0x2fc7d07e,
+		bip3x.LanguageJapanese:           0x0acc1419,
+		bip3x.LanguageKorean:             0x4ef461eb,
+		bip3x.LanguageSpanish:            0x266e4f3d,
+	}
+	// Every supported language has an official list and nothing else is embedded.
+	if len(bip3x.Languages()) != len(checksums) {
+		t.Errorf("got = %v, want = %v", bip3x.Languages(), checksums)
+	}
+	for language, checksum := range checksums {
+		data, err := os.ReadFile(filepath.Join("wordlist", language+".txt"))
+		if err != nil {
+			t.Fatalf("failed to read wordlist: %v", err)
+		}
+		if got := crc32.ChecksumIEEE(data); got != checksum {
+			t.Errorf("%s: got = %08x, want = %08x", language, got, checksum)
+		}
+		if !slices.Contains(bip3x.Languages(), language) {
+			t.Errorf("got = %v, want = %v", bip3x.Languages(), language)
+		}
+	}
+}
+
+func TestJapanese(t *testing.T) {
+	// Vectors from https://github.com/bip32JP/bip32JP.github.io/blob/master/test_JP_BIP39.json.
+	const password = "㍍ガバヴァぱばぐゞちぢ十人十色"
+	tests := []struct {
+		entropy0x string
+		mnemonic  string
+		seed0x    string
+	}{
+		{
+			entropy0x: "00000000000000000000000000000000",
+			mnemonic: "あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　" +
+				"あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あおぞら",
+			seed0x: "a262d6fb6122ecf45be09c50492b31f92e9beb7d9a845987a02cefda57a15f9c" +
+				"467a17872029a9e92299b5cbdf306e3a0ee620245cbd508959b6cb7ca637bd55",
+		},
+		{
+			entropy0x: "7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+			mnemonic: "そつう　れきだい　ほんやく　わかす　りくつ　ばいか　ろせん　やちん　" +
+				"そつう　れきだい　ほんやく　わかめ",
+			seed0x: "aee025cbe6ca256862f889e48110a6a382365142f7d16f2b9545285b3af64e54" +
+				"2143a577e9c144e101a6bdca18f8d97ec3366ebf5b088b1c1af9bc31346e60d9",
+		},
+	}
+	for _, tt := range tests {
+		entropy, _ := hex.DecodeString(tt.entropy0x)
+		mnemonic, err := bip3x.EntropyToMnemonicIn(entropy, bip3x.LanguageJapanese)
+		if err != nil {
+			t.Fatalf("failed to convert entropy to mnemonic: %v", err)
+		}
+		// The official wordlist is decomposed while the vectors are composed.
+		if norm.NFKD.String(mnemonic) != norm.NFKD.String(tt.mnemonic) {
+			t.Errorf("got = %s, want = %s", mnemonic, tt.mnemonic)
+		}
+		for _, variant := range []string{mnemonic, tt.mnemonic, norm.NFC.String(mnemonic)} {
+			seed, err := bip3x.MnemonicToSeed(variant, password)
+			if err != nil {
+				t.Fatalf("failed to convert mnemonic to seed: %v", err)
+			}
+			if seed0x := hex.EncodeToString(seed); seed0x != tt.seed0x {
+				t.Errorf("got = %s, want = %s", seed0x, tt.seed0x)
+			}
+		}
+		detected, err := bip3x.DetectLanguage(mnemonic)
+		if err != nil || !slices.Equal(detected, []string{bip3x.LanguageJapanese}) {
+			t.Errorf("got = %v(%v), want = %v", detected, err, bip3x.LanguageJapanese)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package bip3x
+
+import (
+	"strings"
+)
+
+// LastWord is a valid last word of a mnemonic, with the entropy bits it
+// carries before the digest.
+type LastWord struct {
+	Word        string
+	Entropy     uint32
+	EntropyBits int
+}
+
+// LastWords returns every valid last word of a mnemonic from its other words,
+// such as 11 or 23 words picked by hand, in the order of their entropy bits.
+func LastWords(mnemonic string) ([]LastWord, error) {
+	return LastWordsIn(mnemonic, LanguageEnglish)
+}
+
+// LastWordsIn is LastWords with the words of a language.
+func LastWordsIn(mnemonic string, language string) ([]LastWord, error) {
+	if _, exist := wordlists[language]; !exist {
+		return nil, UnsupportedLanguageError{v: language}
+	}
+	sentence := strings.Fields(strings.ToLower(normalize(mnemonic)))
+	values := make([]uint32, len(sentence)+1)
+	for i, word := range sentence {
+		value, err := wordToValueIn(word, language)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	sentenceBits := len(values) * BitsPerWord
+	if sentenceBits%SentenceBitsStep != 0 || sentenceBits < SentenceBitsMin || sentenceBits > SentenceBitsMax {
+		return nil, InvalidSentenceBitsError{v: sentenceBits}
+	}
+	entropyBits := BitsPerWord - sentenceBits/SentenceBitsStep
+	lastWords := make([]LastWord, 0, 1<<entropyBits)
+	for entropy := range uint32(1 << entropyBits) {
+		values[len(sentence)] = entropy << (BitsPerWord - entropyBits)
+		packed, _, _, err := packValues(values)
+		if err != nil {
+			return nil, err
+		}
+		completed, err := EntropyToMnemonicIn(packed, language)
+		if err != nil {
+			return nil, err
+		}
+		lastWords = append(lastWords, LastWord{
+			Word:        completed[strings.LastIndex(completed, separator(language))+len(separator(language)):],
+			Entropy:     entropy,
+			EntropyBits: entropyBits,
+		})
+	}
+	return lastWords, nil
+}
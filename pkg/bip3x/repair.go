@@ -0,0 +1,168 @@
+package bip3x
+
+import (
+	"cmp"
+	"fmt"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const (
+	// UnknownWord marks a word of the mnemonic that cannot be read at all.
+	UnknownWord = "?"
+
+	maxTypoDistance = 2
+	maxCombinations = 1 << 26
+)
+
+type TooManyCombinationsError struct{ v int }
+
+func (e TooManyCombinationsError) Error() string {
+	return fmt.Sprintf("bip39: too many combinations(more than %v)", e.v)
+}
+
+// Repair is a mnemonic that satisfies the checksum, with its edit distance
+// to the damaged one.
+type Repair struct {
+	Mnemonic string
+	Distance int
+}
+
+type option struct {
+	value    uint32
+	distance int
+}
+
+// RepairMnemonic searches for the mnemonics matching a damaged one, where
+// unknown words are marked with UnknownWord and misspelled words are replaced
+// by the words close to them. If every word is valid but the checksum is not,
+// two words swapped or one word replaced by another are tried instead. The
+// confirm function, which may be nil, filters the candidates further, such as
+// with an expected address, and is called concurrently. The repairs are ranked
+// by edit distance.
+func RepairMnemonic(mnemonic string, confirm func(string) bool) ([]Repair, error) {
+	return RepairMnemonicIn(mnemonic, LanguageEnglish, confirm)
+}
+
+// RepairMnemonicIn is RepairMnemonic with the words of a language.
+func RepairMnemonicIn(mnemonic string, language string, confirm func(string) bool) ([]Repair, error) {
+	list, exist := wordlists[language]
+	if !exist {
+		return nil, UnsupportedLanguageError{v: language}
+	}
+	sentence := strings.Fields(strings.ToLower(normalize(mnemonic)))
+	if sentenceBits := len(sentence) * BitsPerWord; sentenceBits%SentenceBitsStep != 0 ||
+		sentenceBits < SentenceBitsMin || sentenceBits > SentenceBitsMax {
+		return nil, InvalidSentenceBitsError{v: sentenceBits}
+	}
+	options := make([][]option, len(sentence))
+	combinations, valid := 1, true
+	for i, word := range sentence {
+		options[i] = wordOptions(word, language)
+		if combinations *= len(options[i]); combinations > maxCombinations {
+			return nil, TooManyCombinationsError{v: maxCombinations}
+		}
+		valid = valid && len(options[i]) == 1 && options[i][0].distance == 0
+	}
+	repairs := searchRepairs(language, len(sentence), combinations, confirm, func(index int, values []uint32) (int, bool) {
+		distance := 0
+		for i := len(options) - 1; i >= 0; i-- {
+			o := options[i][index%len(options[i])]
+			values[i], distance, index = o.value, distance+o.distance, index/len(options[i])
+		}
+		return distance, true
+	})
+	if len(repairs) != 0 || !valid {
+		return repairs, nil
+	}
+	n := len(sentence)
+	return searchRepairs(language, n, n*n+n*len(list.keys), confirm, func(index int, values []uint32) (int, bool) {
+		for i := range values {
+			values[i] = options[i][0].value
+		}
+		if index < n*n {
+			i, j := index/n, index%n
+			if i >= j || values[i] == values[j] {
+				return 0, false
+			}
+			values[i], values[j] = values[j], values[i]
+			return 1, true
+		}
+		i, value := (index-n*n)/len(list.keys), uint32((index-n*n)%len(list.keys))
+		if values[i] == value {
+			return 0, false
+		}
+		distance := editDistance(list.keys[values[i]], list.keys[value])
+		values[i] = value
+		return distance, true
+	}), nil
+}
+
+// wordOptions returns the words of the language that may have been written as
+// the word, only English words may be abbreviated.
+func wordOptions(word string, language string) []option {
+	list := wordlists[language]
+	if word == UnknownWord {
+		options := make([]option, len(list.keys))
+		for value := range list.keys {
+			options[value] = option{value: uint32(value)}
+		}
+		return options
+	}
+	if value, err := wordToValueIn(word, language); err == nil {
+		return []option{{value: value}}
+	}
+	var options []option
+	for value, candidate := range list.keys {
+		if distance := editDistance(word, candidate); distance <= maxTypoDistance {
+			options = append(options, option{value: uint32(value), distance: distance})
+		}
+	}
+	if len(options) == 0 {
+		options = append(options, option{value: closestValue(word, list.keys), distance: maxTypoDistance + 1})
+	}
+	return options
+}
+
+// searchRepairs splits the combinations among workers, build fills in the
+// values of a combination and returns its distance, or false to skip it.
+func searchRepairs(
+	language string, words int, combinations int, confirm func(string) bool, build func(int, []uint32) (int, bool),
+) []Repair {
+	var mutex sync.Mutex
+	var repairs []Repair
+	var group sync.WaitGroup
+	workers := runtime.NumCPU()
+	for worker := range workers {
+		group.Go(func() {
+			values := make([]uint32, words)
+			for index := worker; index < combinations; index += workers {
+				distance, ok := build(index, values)
+				if !ok {
+					continue
+				}
+				if _, err := valuesToEntropy(values); err != nil {
+					continue
+				}
+				sentence := make([]string, words)
+				for i, value := range values {
+					sentence[i] = wordlists[language].words[value]
+				}
+				mnemonic := strings.Join(sentence, separator(language))
+				if confirm != nil && !confirm(mnemonic) {
+					continue
+				}
+				mutex.Lock()
+				repairs = append(repairs, Repair{Mnemonic: mnemonic, Distance: distance})
+				mutex.Unlock()
+			}
+		})
+	}
+	group.Wait()
+	slices.SortFunc(repairs, func(a, b Repair) int {
+		return cmp.Or(cmp.Compare(a.Distance, b.Distance), strings.Compare(a.Mnemonic, b.Mnemonic))
+	})
+	return repairs
+}
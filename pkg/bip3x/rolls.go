@@ -0,0 +1,70 @@
+package bip3x
+
+import (
+	"fmt"
+	"unicode"
+)
+
+const (
+	DiceSides = 6
+	CoinSides = 2
+)
+
+type InvalidSidesError struct{ v int }
+
+func (e InvalidSidesError) Error() string {
+	return fmt.Sprintf("bip39: invalid sides(%v)", e.v)
+}
+
+type InvalidRollError struct{ v rune }
+
+func (e InvalidRollError) Error() string {
+	return fmt.Sprintf("bip39: invalid roll(%q)", e.v)
+}
+
+// CreateEntropyFromRolls converts dice rolls ("1" to "6") or coin flips ("H"
+// or "1" for heads, "T" or "0" for tails) into entropy, blanks are ignored
+// and anything else is rejected. A roll of 1 to 4 gives 2 bits and a roll of
+// 5 or 6 gives 1 bit, so every bit is unbiased for a fair die. It returns the
+// number of bits taken from the rolls, the rest of the entropy is left zero
+// when there are too few of them, while rolls beyond the size are ignored.
+func CreateEntropyFromRolls(rolls string, sides int, entropySize int) ([]byte, int, error) {
+	if entropySize%EntropyBitsStep != 0 || entropySize < EntropyBitsMin || entropySize > EntropyBitsMax {
+		return nil, 0, InvalidEntropyBitsError{v: entropySize}
+	}
+	if sides != DiceSides && sides != CoinSides {
+		return nil, 0, InvalidSidesError{v: sides}
+	}
+	entropy := make([]byte, entropySize/BitsPerByte)
+	bits := 0
+	push := func(value, count int) {
+		for i := count - 1; i >= 0 && bits < entropySize; i-- {
+			entropy[bits/BitsPerByte] |= byte((value>>i)&1) << (BitsPerByte - 1 - bits%BitsPerByte)
+			bits++
+		}
+	}
+	for _, roll := range rolls {
+		switch {
+		case unicode.IsSpace(roll) || roll == ',':
+		case sides == DiceSides && roll >= '1' && roll <= '4':
+			push(int(roll-'1'), 2)
+		case sides == DiceSides && (roll == '5' || roll == '6'):
+			push(int(roll-'5'), 1)
+		case sides == CoinSides && (roll == 'H' || roll == 'h' || roll == '1'):
+			push(1, 1)
+		case sides == CoinSides && (roll == 'T' || roll == 't' || roll == '0'):
+			push(0, 1)
+		default:
+			return nil, 0, InvalidRollError{v: roll}
+		}
+	}
+	return entropy, bits, nil
+}
+
+// RollBits returns the number of bits a roll gives on average.
+func RollBits(sides int) float64 {
+	if sides == DiceSides {
+		return (4*2 + 2*1) / float64(DiceSides)
+	}
+	return 1
+}
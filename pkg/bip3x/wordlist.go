@@ -0,0 +1,134 @@
+package bip3x
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	LanguageEnglish            = "english"
+	LanguageChineseSimplified  = "chinese_simplified"
+	LanguageChineseTraditional = "chinese_traditional"
+	LanguageCzech              = "czech"
+	LanguageFrench             = "french"
+	LanguageItalian            = "italian"
+	LanguageJapanese           = "japanese"
+	LanguageKorean             = "korean"
+	LanguageSpanish            = "spanish"
+
+	wordsPerList = 1 << BitsPerWord
+	// ideographicSpace separates the words of Japanese mnemonics, NFKD turns
+	// it into an ASCII space before hashing.
+	ideographicSpace = "　"
+)
+
+type UnsupportedLanguageError struct{ v string }
+
+func (e UnsupportedLanguageError) Error() string {
+	return fmt.Sprintf("bip39: unsupported language(%s)", e.v)
+}
+
+// languages is the order in which mnemonics are matched against the lists,
+// the Chinese lists share many characters so the simplified one goes first.
+var languages = []string{
+	LanguageEnglish, LanguageChineseSimplified, LanguageChineseTraditional, LanguageCzech, LanguageFrench,
+	LanguageItalian, LanguageJapanese, LanguageKorean, LanguageSpanish,
+}
+
+// wordlist keeps the words as written in the official list, and looks them
+// up by their normalized form.
+type wordlist struct {
+	words  []string
+	keys   []string
+	values map[string]uint32
+}
+
+var (
+	// The official lists are named after their language, there must be one
+	// for each of the languages and no other.
+	//go:embed wordlist/*.txt
+	wordlistFS embed.FS
+	wordlists  = loadWordlists()
+)
+
+func loadWordlists() map[string]*wordlist {
+	entries, err := wordlistFS.ReadDir("wordlist")
+	if err != nil {
+		panic(fmt.Sprintf("bip39: failed to read wordlists: %v", err))
+	}
+	for _, entry := range entries {
+		if language := strings.TrimSuffix(entry.Name(), path.Ext(entry.Name())); !slices.Contains(languages, language) {
+			panic(fmt.Sprintf("bip39: unknown wordlist %s", language))
+		}
+	}
+	lists := make(map[string]*wordlist, len(languages))
+	for _, language := range languages {
+		data, err := wordlistFS.ReadFile("wordlist/" + language + ".txt")
+		if err != nil {
+			panic(fmt.Sprintf("bip39: failed to read wordlist %s: %v", language, err))
+		}
+		words := strings.Fields(string(data))
+		if len(words) != wordsPerList {
+			panic(fmt.Sprintf("bip39: invalid wordlist %s", language))
+		}
+		list := &wordlist{words: words, keys: make([]string, len(words)), values: make(map[string]uint32, len(words))}
+		for value, word := range words {
+			list.keys[value] = normalize(word)
+			list.values[list.keys[value]] = uint32(value)
+		}
+		lists[language] = list
+	}
+	return lists
+}
+
+// Languages returns the languages of the embedded wordlists.
+func Languages() []string {
+	return slices.Clone(languages)
+}
+
+// DetectLanguage returns the languages whose wordlist holds every word of
+// the mnemonic, several of them for words shared by the Chinese lists.
+func DetectLanguage(mnemonic string) ([]string, error) {
+	sentence := strings.Fields(normalize(mnemonic))
+	if len(sentence) == 0 {
+		return nil, InvalidSentenceBitsError{v: 0}
+	}
+	var detected []string
+	var missing string
+	for _, language := range Languages() {
+		list := wordlists[language]
+		index := slices.IndexFunc(sentence, func(word string) bool {
+			_, exist := list.values[word]
+			return !exist
+		})
+		if index < 0 {
+			detected = append(detected, language)
+		} else if language == LanguageEnglish || (len(missing) == 0 && index > 0) {
+			missing = sentence[index]
+		}
+	}
+	if len(detected) == 0 {
+		return nil, WordNotExistError{v: missing}
+	}
+	return detected, nil
+}
+
+// separator joins the words of a mnemonic for display.
+func separator(language string) string {
+	if language == LanguageJapanese {
+		return ideographicSpace
+	}
+	return " "
+}
+
+// normalize applies NFKD as BIP39 requires, so that the composed and the
+// decomposed forms of accented letters, or the full width forms of Japanese,
+// lead to the same seed.
+func normalize(text string) string {
+	return norm.NFKD.String(text)
+}
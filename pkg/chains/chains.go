@@ -0,0 +1,120 @@
+package chains
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"math/big"
+	"slices"
+	"unicode"
+
+	"github.com/rbee3u/dpass/pkg/base58"
+	"github.com/rbee3u/dpass/pkg/bech32"
+	"github.com/rbee3u/dpass/pkg/bip3x"
+	"github.com/rbee3u/dpass/pkg/hashx"
+	"github.com/rbee3u/dpass/pkg/secp256k1"
+)
+
+const h = bip3x.FirstHardenedChild
+
+// DeriveAddress derives the address at the default path of each dcoin chain,
+// without passphrase, which is enough to recognize a wallet without exposing
+// any secret.
+var DeriveAddress = map[string]func(string) (string, error){
+	"bitcoin": func(mnemonic string) (string, error) {
+		return deriveSecp256k1(mnemonic, []uint32{84 + h, 0 + h, 0 + h, 0, 0}, func(x, y *big.Int) string {
+			return bech32.Encode("bc", []byte{0}, hashx.RipeMD160Sum(hashx.Sha256Sum(CompressPk(x, y))))
+		})
+	},
+	"ethereum": func(mnemonic string) (string, error) {
+		return deriveSecp256k1(mnemonic, []uint32{44 + h, 60 + h, 0 + h, 0, 0}, EthereumAddress)
+	},
+	"tron": func(mnemonic string) (string, error) {
+		return deriveSecp256k1(mnemonic, []uint32{44 + h, 195 + h, 0 + h, 0, 0}, TronAddress)
+	},
+	"solana": func(mnemonic string) (string, error) {
+		return deriveEd25519(mnemonic, []uint32{44 + h, 501 + h, 0 + h, 0 + h, 0 + h}, SolanaAddress)
+	},
+	"dogecoin": func(mnemonic string) (string, error) {
+		return deriveSecp256k1(mnemonic, []uint32{44 + h, 3 + h, 0 + h, 0, 0}, DogecoinAddress)
+	},
+	"sui": func(mnemonic string) (string, error) {
+		return deriveEd25519(mnemonic, []uint32{44 + h, 784 + h, 0 + h, 0 + h, 0 + h}, SuiAddress)
+	},
+}
+
+// Names returns the names of the chains in order.
+func Names() []string {
+	return slices.Sorted(maps.Keys(DeriveAddress))
+}
+
+func deriveSecp256k1(mnemonic string, path []uint32, pkToAddress func(x, y *big.Int) string) (string, error) {
+	seed, err := bip3x.MnemonicToSeed(mnemonic, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to convert mnemonic to seed: %w", err)
+	}
+	sk, err := bip3x.Secp256k1DeriveSk(seed, path)
+	if err != nil {
+		return "", fmt.Errorf("failed to derive sk: %w", err)
+	}
+	return pkToAddress(secp256k1.S256().ScalarBaseMult(sk)), nil
+}
+
+func deriveEd25519(mnemonic string, path []uint32, pkToAddress func(pk []byte) string) (string, error) {
+	seed, err := bip3x.MnemonicToSeed(mnemonic, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to convert mnemonic to seed: %w", err)
+	}
+	sk, err := bip3x.Ed25519DeriveSk(seed, path)
+	if err != nil {
+		return "", fmt.Errorf("failed to derive sk: %w", err)
+	}
+	return pkToAddress(ed25519.NewKeyFromSeed(sk)[ed25519.SeedSize:]), nil
+}
+
+// CompressPk serializes a secp256k1 public key in its compressed form.
+func CompressPk(x, y *big.Int) []byte {
+	data := make([]byte, 33)
+	data[0] = 2
+	x.FillBytes(data[1:33])
+	data[0] += byte(y.Bit(0))
+	return data
+}
+
+func EthereumAddress(x, y *big.Int) string {
+	pk := make([]byte, 64)
+	x.FillBytes(pk[:32])
+	y.FillBytes(pk[32:])
+	data := []byte(hex.EncodeToString(hashx.Keccak256Sum(pk)[12:]))
+	digest := hashx.Keccak256Sum(data)
+	for i := range data {
+		if ((digest[i/2]>>(4-i%2*4))&0b1000) != 0 && unicode.IsLower(rune(data[i])) {
+			data[i] = byte(unicode.ToUpper(rune(data[i])))
+		}
+	}
+	return "0x" + string(data)
+}
+
+func TronAddress(x, y *big.Int) string {
+	pk := make([]byte, 64)
+	x.FillBytes(pk[:32])
+	y.FillBytes(pk[32:])
+	data := slices.Concat([]byte{'A'}, hashx.Keccak256Sum(pk)[12:])
+	digest := hashx.Sha256Sum(hashx.Sha256Sum(data))[:4]
+	return base58.Encode(slices.Concat(data, digest))
+}
+
+func DogecoinAddress(x, y *big.Int) string {
+	data := slices.Concat([]byte{0x1e}, hashx.RipeMD160Sum(hashx.Sha256Sum(CompressPk(x, y))))
+	digest := hashx.Sha256Sum(hashx.Sha256Sum(data))[:4]
+	return base58.Encode(slices.Concat(data, digest))
+}
+
+func SolanaAddress(pk []byte) string {
+	return base58.Encode(pk)
+}
+
+func SuiAddress(pk []byte) string {
+	return "0x" + hex.EncodeToString(hashx.Blake2b256Sum(slices.Concat([]byte{0}, pk)))
+}
@@ -0,0 +1,34 @@
+package chains_test
+
+import (
+	"testing"
+
+	"github.com/rbee3u/dpass/pkg/chains"
+)
+
+func TestDeriveAddress(t *testing.T) {
+	mnemonic := "daughter very gossip boil void ghost that obtain crew retreat obey direct brain bulb grow edge shield join hotel genius concert gain later account"
+	tests := map[string]string{
+		"bitcoin":  "bc1qpeft30lweh28g9yaq20h0mfdjensap49l98jft",
+		"dogecoin": "DDmog5ZadHMuQek9i3PMkpLQcPpBEPoy76",
+		"ethereum": "0xF2E68B8894e098AB6b5936906AB5ea73De03712E",
+		"solana":   "5jn67z6icfWYToBodAnn28CJENiq4R7CCEJn3RWQmpk6",
+		"sui":      "0xa3dd6730e699123c698ea2e5adb1c7ed423a0678d2b415313df494dd3b4cc4c8",
+		"tron":     "TFT56sLfzr8z1VsHrjfWDPTvmmNKq2YsLf",
+	}
+	if got, want := len(chains.Names()), len(tests); got != want {
+		t.Fatalf("got = %v, want = %v", got, want)
+	}
+	for _, name := range chains.Names() {
+		address, err := chains.DeriveAddress[name](mnemonic)
+		if err != nil {
+			t.Fatalf("failed to derive address: %v", err)
+		}
+		if address != tests[name] {
+			t.Errorf("%s: got = %s, want = %s", name, address, tests[name])
+		}
+	}
+	if _, err := chains.DeriveAddress["ethereum"]("daughter very"); err == nil {
+		t.Errorf("got = %v, want = %v", err, "error")
+	}
+}
@@ -0,0 +1,136 @@
+package qrdecode
+
+import (
+	"image"
+)
+
+const (
+	blockSize       = 8
+	minDynamicRange = 24
+	minHybridSize   = 5 * blockSize
+)
+
+// bitmap is a black and white image, true for dark.
+type bitmap struct {
+	width, height int
+	dark          []bool
+}
+
+func (b *bitmap) at(x, y int) bool {
+	return b.inside(x, y) && b.dark[y*b.width+x]
+}
+
+func (b *bitmap) inside(x, y int) bool {
+	return x >= 0 && y >= 0 && x < b.width && y < b.height
+}
+
+// binarize thresholds each block of the image against the average of the
+// blocks around it, which copes with the uneven lighting of photos.
+func binarize(img image.Image) *bitmap {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	luminance := make([]int, width*height)
+	for y := range height {
+		for x := range width {
+			r, g, b, _ := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			luminance[y*width+x] = int(299*r+587*g+114*b) / 1000 >> 8
+		}
+	}
+	matrix := &bitmap{width: width, height: height, dark: make([]bool, width*height)}
+	if width < minHybridSize || height < minHybridSize {
+		threshold := otsu(luminance)
+		for i, l := range luminance {
+			matrix.dark[i] = l <= threshold
+		}
+		return matrix
+	}
+	subWidth, subHeight := (width+blockSize-1)/blockSize, (height+blockSize-1)/blockSize
+	blacks := make([][]int, subHeight)
+	for by := range subHeight {
+		blacks[by] = make([]int, subWidth)
+		// The last blocks are shifted inward to stay in the image.
+		y0 := min(by*blockSize, height-blockSize)
+		for bx := range subWidth {
+			x0 := min(bx*blockSize, width-blockSize)
+			sum, lo, hi := 0, 255, 0
+			for y := y0; y < y0+blockSize; y++ {
+				for x := x0; x < x0+blockSize; x++ {
+					l := luminance[y*width+x]
+					sum += l
+					lo, hi = min(lo, l), max(hi, l)
+				}
+			}
+			average := sum / (blockSize * blockSize)
+			if hi-lo <= minDynamicRange {
+				// A flat block is taken as background unless its neighbors
+				// say it lies in a dark area.
+				average = lo / 2
+				if by > 0 && bx > 0 {
+					neighbors := (blacks[by-1][bx] + 2*blacks[by][bx-1] + blacks[by-1][bx-1]) / 4
+					if lo < neighbors {
+						average = neighbors
+					}
+				}
+			}
+			blacks[by][bx] = average
+		}
+	}
+	for by := range subHeight {
+		y0 := min(by*blockSize, height-blockSize)
+		cy := min(max(by, 2), subHeight-3)
+		for bx := range subWidth {
+			x0 := min(bx*blockSize, width-blockSize)
+			cx := min(max(bx, 2), subWidth-3)
+			sum := 0
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					sum += blacks[cy+dy][cx+dx]
+				}
+			}
+			threshold := sum / 25
+			for y := y0; y < y0+blockSize; y++ {
+				for x := x0; x < x0+blockSize; x++ {
+					matrix.dark[y*width+x] = luminance[y*width+x] <= threshold
+				}
+			}
+		}
+	}
+	return matrix
+}
+
+// otsu returns the threshold that best separates the two classes of the
+// histogram, for images too small to be split in blocks.
+func otsu(luminance []int) int {
+	var histogram [256]int
+	for _, l := range luminance {
+		histogram[l]++
+	}
+	total, sum := len(luminance), 0
+	for l, count := range histogram {
+		sum += l * count
+	}
+	best, threshold := -1.0, 127
+	weight, partial := 0, 0
+	for l, count := range histogram {
+		weight += count
+		partial += l * count
+		if weight == 0 || weight == total {
+			continue
+		}
+		mean0 := float64(partial) / float64(weight)
+		mean1 := float64(sum-partial) / float64(total-weight)
+		between := float64(weight) * float64(total-weight) * (mean0 - mean1) * (mean0 - mean1)
+		if between > best {
+			best, threshold = between, l
+		}
+	}
+	return threshold
+}
+
+func (b *bitmap) invert() *bitmap {
+	inverted := &bitmap{width: b.width, height: b.height, dark: make([]bool, len(b.dark))}
+	for i, dark := range b.dark {
+		inverted.dark[i] = !dark
+	}
+	return inverted
+}
@@ -0,0 +1,405 @@
+package qrdecode
+
+import (
+	"fmt"
+	"image"
+	"math/bits"
+
+	"rsc.io/qr/coding"
+)
+
+const (
+	formatPoly   = 0x537
+	formatMask   = 0x5412
+	versionPoly  = 0x1f25
+	maxBitErrors = 3
+
+	modeTerminator = 0
+	modeNumeric    = 1
+	modeAlpha      = 2
+	modeStructured = 3
+	modeByte       = 4
+	modeECI        = 7
+	modeKanji      = 8
+
+	alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+)
+
+type NotFoundError struct{ v int }
+
+func (e NotFoundError) Error() string {
+	return fmt.Sprintf("qrdecode: found %v of 3 finder patterns", e.v)
+}
+
+type InvalidSizeError struct{ v int }
+
+func (e InvalidSizeError) Error() string {
+	return fmt.Sprintf("qrdecode: invalid size(%v)", e.v)
+}
+
+type InvalidFormatError struct{ v uint32 }
+
+func (e InvalidFormatError) Error() string {
+	return fmt.Sprintf("qrdecode: invalid format(%#x)", e.v)
+}
+
+type InvalidVersionError struct{ v uint32 }
+
+func (e InvalidVersionError) Error() string {
+	return fmt.Sprintf("qrdecode: invalid version(%#x)", e.v)
+}
+
+type UncorrectableError struct{ v int }
+
+func (e UncorrectableError) Error() string {
+	return fmt.Sprintf("qrdecode: uncorrectable block with %v errors", e.v)
+}
+
+type UnsupportedModeError struct{ v int }
+
+func (e UnsupportedModeError) Error() string {
+	return fmt.Sprintf("qrdecode: unsupported mode(%v)", e.v)
+}
+
+type InvalidDataError struct{ v int }
+
+func (e InvalidDataError) Error() string {
+	return fmt.Sprintf("qrdecode: invalid data at bit(%v)", e.v)
+}
+
+// Grid holds the modules of a code, true for dark.
+type Grid [][]bool
+
+// Decode finds a QR code in the image and returns its payload.
+func Decode(img image.Image) ([]byte, error) {
+	matrix := binarize(img)
+	candidates, err := locate(matrix)
+	if err != nil {
+		// Light modules on a dark background, as drawn by qrcode --swap.
+		if candidates, _ = locate(matrix.invert()); candidates == nil {
+			return nil, err
+		}
+	}
+	var firstErr error
+	for _, grid := range candidates {
+		payload, err := DecodeGrid(grid)
+		if err == nil {
+			return payload, nil
+		}
+		// A code printed or photographed mirrored reads as its transpose.
+		if payload, err := DecodeGrid(grid.transpose()); err == nil {
+			return payload, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return nil, firstErr
+}
+
+// DecodeGrid decodes the modules of a code already sampled from an image.
+func DecodeGrid(grid Grid) ([]byte, error) {
+	size := len(grid)
+	if size < minSize || size > maxSize || (size-17)%4 != 0 {
+		return nil, InvalidSizeError{v: size}
+	}
+	level, mask, err := readFormat(grid)
+	if err != nil {
+		return nil, err
+	}
+	version := coding.Version((size - 17) / 4)
+	if version >= 7 {
+		if version, err = readVersion(grid); err != nil {
+			return nil, err
+		}
+		if int(version)*4+17 != size {
+			return nil, InvalidSizeError{v: size}
+		}
+	}
+	plan, err := coding.NewPlan(version, level, mask)
+	if err != nil {
+		return nil, fmt.Errorf("qrdecode: %w", err)
+	}
+	stream := make([]byte, plan.DataBytes+plan.CheckBytes)
+	for y, row := range plan.Pixel {
+		for x, pixel := range row {
+			if role := pixel.Role(); role != coding.Data && role != coding.Check {
+				continue
+			}
+			if grid[y][x] != (pixel&coding.Black != 0) {
+				offset := pixel.Offset()
+				stream[offset/8] |= 1 << (7 - offset%8)
+			}
+		}
+	}
+	data, err := splitBlocks(stream, plan)
+	if err != nil {
+		return nil, err
+	}
+	return parseSegments(data, version)
+}
+
+func (g Grid) transpose() Grid {
+	transposed := make(Grid, len(g))
+	for y := range transposed {
+		transposed[y] = make([]bool, len(g))
+		for x := range transposed[y] {
+			transposed[y][x] = g[x][y]
+		}
+	}
+	return transposed
+}
+
+// readFormat reads both copies of the format and picks the closest valid
+// one, the format survives up to three flipped bits.
+func readFormat(grid Grid) (coding.Level, coding.Mask, error) {
+	size := len(grid)
+	var first, second uint32
+	for i := range 15 {
+		var y, x int
+		switch {
+		case i < 6:
+			y, x = i, 8
+		case i < 8:
+			y, x = i+1, 8
+		case i < 9:
+			y, x = 8, 7
+		default:
+			y, x = 8, 14-i
+		}
+		if grid[y][x] {
+			first |= 1 << i
+		}
+		if i < 8 {
+			y, x = 8, size-1-i
+		} else {
+			y, x = size-1-(14-i), 8
+		}
+		if grid[y][x] {
+			second |= 1 << i
+		}
+	}
+	bestDistance, bestLevel, bestMask := maxBitErrors+1, coding.L, coding.Mask(0)
+	for level := coding.L; level <= coding.H; level++ {
+		for mask := range coding.Mask(8) {
+			codeword := formatCodeword(level, mask)
+			distance := min(bits.OnesCount32(codeword^first), bits.OnesCount32(codeword^second))
+			if distance < bestDistance {
+				bestDistance, bestLevel, bestMask = distance, level, mask
+			}
+		}
+	}
+	if bestDistance > maxBitErrors {
+		return 0, 0, InvalidFormatError{v: first}
+	}
+	return bestLevel, bestMask, nil
+}
+
+// formatCodeword is the format as drawn by coding.NewPlan, the level is
+// encoded as L=01, M=00, Q=11, H=10.
+func formatCodeword(level coding.Level, mask coding.Mask) uint32 {
+	value := uint32(level^1)<<13 | uint32(mask)<<10
+	return (value | remainder(value, formatPoly, 14, 10)) ^ formatMask
+}
+
+func remainder(value, poly uint32, top, degree int) uint32 {
+	for i := top; i >= degree; i-- {
+		if value&(1<<i) != 0 {
+			value ^= poly << (i - degree)
+		}
+	}
+	return value
+}
+
+// readVersion reads both copies of the version of large codes.
+func readVersion(grid Grid) (coding.Version, error) {
+	size := len(grid)
+	var first, second uint32
+	for i := range 18 {
+		x, y := i/3, size-11+i%3
+		if grid[y][x] {
+			first |= 1 << i
+		}
+		if grid[x][y] {
+			second |= 1 << i
+		}
+	}
+	bestDistance, bestVersion := maxBitErrors+1, coding.Version(0)
+	for version := coding.Version(7); version <= coding.MaxVersion; version++ {
+		value := uint32(version) << 12
+		codeword := value | remainder(value, versionPoly, 17, 12)
+		distance := min(bits.OnesCount32(codeword^first), bits.OnesCount32(codeword^second))
+		if distance < bestDistance {
+			bestDistance, bestVersion = distance, version
+		}
+	}
+	if bestDistance > maxBitErrors {
+		return 0, InvalidVersionError{v: first}
+	}
+	return bestVersion, nil
+}
+
+// splitBlocks corrects each block of the stream and joins their data, the
+// offsets of coding.Plan already undo the interleaving so the stream holds
+// the data of all blocks followed by their check bytes.
+func splitBlocks(stream []byte, plan *coding.Plan) ([]byte, error) {
+	blocks := plan.Blocks
+	check := plan.CheckBytes / blocks
+	short := plan.DataBytes / blocks
+	extra := plan.DataBytes % blocks
+	data, checks := stream[:plan.DataBytes], stream[plan.DataBytes:]
+	var result []byte
+	for i := range blocks {
+		size := short
+		if i >= blocks-extra {
+			size++
+		}
+		block := append(data[:size:size], checks[:check]...)
+		if err := correct(block, check); err != nil {
+			return nil, err
+		}
+		result = append(result, block[:size]...)
+		data, checks = data[size:], checks[check:]
+	}
+	return result, nil
+}
+
+type bitReader struct {
+	data   []byte
+	offset int
+}
+
+func (r *bitReader) available() int {
+	return len(r.data)*8 - r.offset
+}
+
+func (r *bitReader) read(n int) (int, error) {
+	if n > r.available() {
+		return 0, InvalidDataError{v: r.offset}
+	}
+	value := 0
+	for range n {
+		bit := r.data[r.offset/8] >> (7 - r.offset%8) & 1
+		value = value<<1 | int(bit)
+		r.offset++
+	}
+	return value, nil
+}
+
+// countBits returns the width of the character count of a mode, which grows
+// with the version.
+func countBits(mode int, version coding.Version) int {
+	class := 0
+	switch {
+	case version >= 27:
+		class = 2
+	case version >= 10:
+		class = 1
+	}
+	switch mode {
+	case modeNumeric:
+		return [3]int{10, 12, 14}[class]
+	case modeAlpha:
+		return [3]int{9, 11, 13}[class]
+	case modeByte:
+		return [3]int{8, 16, 16}[class]
+	default:
+		return [3]int{8, 10, 12}[class]
+	}
+}
+
+func parseSegments(data []byte, version coding.Version) ([]byte, error) {
+	r := &bitReader{data: data}
+	payload := []byte{}
+	for r.available() >= 4 {
+		mode, _ := r.read(4)
+		switch mode {
+		case modeTerminator:
+			return payload, nil
+		case modeStructured:
+			// Index, count and parity of a symbol in a sequence.
+			if _, err := r.read(16); err != nil {
+				return nil, err
+			}
+			continue
+		case modeECI:
+			// The payload is returned as raw bytes whatever the charset.
+			first, err := r.read(8)
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case first&0x80 == 0:
+			case first&0xc0 == 0x80:
+				_, err = r.read(8)
+			default:
+				_, err = r.read(16)
+			}
+			if err != nil {
+				return nil, err
+			}
+			continue
+		case modeNumeric, modeAlpha, modeByte:
+		default:
+			return nil, UnsupportedModeError{v: mode}
+		}
+		count, err := r.read(countBits(mode, version))
+		if err != nil {
+			return nil, err
+		}
+		switch mode {
+		case modeNumeric:
+			payload, err = readNumeric(r, payload, count)
+		case modeAlpha:
+			payload, err = readAlpha(r, payload, count)
+		default:
+			for range count {
+				var b int
+				if b, err = r.read(8); err != nil {
+					break
+				}
+				payload = append(payload, byte(b))
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return payload, nil
+}
+
+func readNumeric(r *bitReader, payload []byte, count int) ([]byte, error) {
+	for count > 0 {
+		digits := min(count, 3)
+		value, err := r.read([4]int{0, 4, 7, 10}[digits])
+		if err != nil {
+			return nil, err
+		}
+		text := fmt.Sprintf("%0*d", digits, value)
+		if len(text) != digits {
+			return nil, InvalidDataError{v: r.offset}
+		}
+		payload = append(payload, text...)
+		count -= digits
+	}
+	return payload, nil
+}
+
+func readAlpha(r *bitReader, payload []byte, count int) ([]byte, error) {
+	for count > 0 {
+		if count == 1 {
+			value, err := r.read(6)
+			if err != nil || value >= len(alphabet) {
+				return nil, InvalidDataError{v: r.offset}
+			}
+			return append(payload, alphabet[value]), nil
+		}
+		value, err := r.read(11)
+		if err != nil || value >= len(alphabet)*len(alphabet) {
+			return nil, InvalidDataError{v: r.offset}
+		}
+		payload = append(payload, alphabet[value/len(alphabet)], alphabet[value%len(alphabet)])
+		count -= 2
+	}
+	return payload, nil
+}
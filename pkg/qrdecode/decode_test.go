@@ -0,0 +1,190 @@
+package qrdecode
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+	"testing"
+
+	"rsc.io/qr"
+	"rsc.io/qr/coding"
+	"rsc.io/qr/gf256"
+)
+
+// render draws a code with a quiet zone of four modules, mapping each pixel
+// of the image back onto the code through the inverse transform.
+func render(code *qr.Code, scale, width int, inverse func(x, y float64) (float64, float64)) image.Image {
+	img := image.NewGray(image.Rect(0, 0, width, width))
+	for y := range width {
+		for x := range width {
+			cx, cy := inverse(float64(x)+0.5, float64(y)+0.5)
+			mx, my := int(math.Floor(cx/float64(scale)))-4, int(math.Floor(cy/float64(scale)))-4
+			img.SetGray(x, y, color.Gray{Y: 255})
+			if mx >= 0 && my >= 0 && mx < code.Size && my < code.Size && code.Black(mx, my) {
+				img.SetGray(x, y, color.Gray{Y: 0})
+			}
+		}
+	}
+	return img
+}
+
+func identity(x, y float64) (float64, float64) { return x, y }
+
+func TestDecode(t *testing.T) {
+	text := "-----BEGIN SHAMIR-----\nI: 1\nM: 2\nN: 3\n\nq83vEjRWeJq83vEjRWeJq83vEjRWeJq83vEjRWeJ\n-----END SHAMIR-----\n"
+	tests := []struct {
+		name    string
+		text    string
+		level   qr.Level
+		scale   int
+		pad     int
+		invert  bool
+		inverse func(size float64) func(x, y float64) (float64, float64)
+	}{
+		{name: "plain", text: "hello", level: qr.L, scale: 4},
+		{name: "medium", text: text, level: qr.M, scale: 3},
+		{name: "quartile", text: text, level: qr.Q, scale: 5},
+		{name: "high", text: text, level: qr.H, scale: 4},
+		{name: "large", text: strings.Repeat(text, 3), level: qr.M, scale: 3},
+		{name: "inverted", text: text, level: qr.L, scale: 4, invert: true},
+		{name: "mirrored", text: text, level: qr.M, scale: 4, inverse: func(size float64) func(x, y float64) (float64, float64) {
+			return func(x, y float64) (float64, float64) { return size - x, y }
+		}},
+		{name: "rotated", text: text, level: qr.M, scale: 5, pad: 8, inverse: func(size float64) func(x, y float64) (float64, float64) {
+			sin, cos := math.Sincos(0.5)
+			return func(x, y float64) (float64, float64) {
+				x, y = x-size/2, y-size/2
+				return x*cos - y*sin + size/2, x*sin + y*cos + size/2
+			}
+		}},
+		{name: "perspective", text: text, level: qr.M, scale: 5, inverse: func(size float64) func(x, y float64) (float64, float64) {
+			return func(x, y float64) (float64, float64) {
+				w := 1 + 0.0004*x
+				return x * w, y*w - 0.05*x
+			}
+		}},
+	}
+	for _, tt := range tests {
+		code, err := qr.Encode(tt.text, tt.level)
+		if err != nil {
+			t.Fatalf("failed to encode: %v", err)
+		}
+		width := (code.Size + 8 + 2*tt.pad) * tt.scale
+		inverse := identity
+		if tt.inverse != nil {
+			inverse = tt.inverse(float64(width))
+		}
+		offset := float64(tt.pad * tt.scale)
+		img := render(code, tt.scale, width, func(x, y float64) (float64, float64) {
+			x, y = inverse(x, y)
+			return x - offset, y - offset
+		})
+		if tt.invert {
+			gray := img.(*image.Gray)
+			for i := range gray.Pix {
+				gray.Pix[i] = 255 - gray.Pix[i]
+			}
+		}
+		payload, err := Decode(img)
+		if err != nil {
+			t.Fatalf("failed to decode %s: %v", tt.name, err)
+		}
+		if string(payload) != tt.text {
+			t.Errorf("%s: got = %q, want = %q", tt.name, payload, tt.text)
+		}
+	}
+}
+
+func TestDecodePGM(t *testing.T) {
+	text := "ur:bytes/hdcxlkahssqzwfvslofzoxwkrewngotktbmwjkwdcmnefsaaehrlolkskncnktlbaypkrphsmyid"
+	code, err := qr.Encode(text, qr.L)
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	gray := render(code, 3, (code.Size+8)*3, identity).(*image.Gray)
+	binary := fmt.Appendf(nil, "P5\n# scanned\n%v %v\n255\n", gray.Rect.Dx(), gray.Rect.Dy())
+	binary = append(binary, gray.Pix...)
+	plain := fmt.Appendf(nil, "P2 %v %v 15\n", gray.Rect.Dx(), gray.Rect.Dy())
+	for _, pixel := range gray.Pix {
+		plain = fmt.Appendf(plain, "%v ", pixel/17)
+	}
+	for _, data := range [][]byte{binary, plain} {
+		img, format, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("failed to decode image: %v", err)
+		}
+		if format != "pgm" {
+			t.Errorf("got = %v, want = %v", format, "pgm")
+		}
+		payload, err := Decode(img)
+		if err != nil {
+			t.Fatalf("failed to decode: %v", err)
+		}
+		if string(payload) != text {
+			t.Errorf("got = %q, want = %q", payload, text)
+		}
+	}
+	if _, err := DecodePGM(bytes.NewReader(binary[:len(binary)-1])); err == nil {
+		t.Errorf("got = nil, want = error")
+	}
+}
+
+func TestDecodeGrid(t *testing.T) {
+	text := strings.Repeat("correct horse battery staple ", 4)
+	code, err := qr.Encode(text, qr.Q)
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	grid := make(Grid, code.Size)
+	for y := range grid {
+		grid[y] = make([]bool, code.Size)
+		for x := range grid[y] {
+			grid[y][x] = code.Black(x, y)
+		}
+	}
+	// Flip a run of modules in the data area, within what the level corrects.
+	for x := 9; x < 17; x++ {
+		grid[code.Size/2][x] = !grid[code.Size/2][x]
+	}
+	payload, err := DecodeGrid(grid)
+	if err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+	if string(payload) != text {
+		t.Errorf("got = %q, want = %q", payload, text)
+	}
+	if _, err := DecodeGrid(grid[1:]); err == nil {
+		t.Errorf("got = nil, want = error")
+	}
+}
+
+func TestCorrect(t *testing.T) {
+	data := []byte("reed solomon")
+	check := 10
+	block := make([]byte, len(data)+check)
+	copy(block, data)
+	gf256.NewRSEncoder(coding.Field, check).ECC(data, block[len(data):])
+	want := bytes.Clone(block)
+	for _, errors := range []int{0, 1, 3, 5} {
+		damaged := bytes.Clone(want)
+		for i := range errors {
+			damaged[i*4] ^= byte(0x5a + i)
+		}
+		if err := correct(damaged, check); err != nil {
+			t.Fatalf("failed to correct %v errors: %v", errors, err)
+		}
+		if !bytes.Equal(damaged, want) {
+			t.Errorf("got = %x, want = %x", damaged, want)
+		}
+	}
+	damaged := bytes.Clone(want)
+	for i := range 6 {
+		damaged[i*3] ^= 0xff
+	}
+	if err := correct(damaged, check); err == nil && bytes.Equal(damaged, want) {
+		t.Errorf("got = corrected, want = error")
+	}
+}
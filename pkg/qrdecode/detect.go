@@ -0,0 +1,337 @@
+package qrdecode
+
+import (
+	"math"
+	"slices"
+)
+
+const (
+	maxScanRows     = 1000
+	minSize         = 21
+	maxSize         = 177
+	maxCandidates   = 12
+	alignmentRadius = 4
+	minAlignScore   = 21
+)
+
+type point struct{ x, y float64 }
+
+func distance(a, b point) float64 {
+	return math.Hypot(a.x-b.x, a.y-b.y)
+}
+
+// finder is a candidate center of a finder pattern, count is the number of
+// rows it was confirmed on.
+type finder struct {
+	point
+	moduleSize float64
+	count      int
+}
+
+// locate finds the three finder patterns and samples the modules between
+// them, a few sizes around the estimated one are returned to be tried.
+func locate(matrix *bitmap) ([]Grid, error) {
+	finders := findFinders(matrix)
+	bottomLeft, topLeft, topRight, err := selectFinders(finders)
+	if err != nil {
+		return nil, err
+	}
+	moduleSize := (bottomLeft.moduleSize + topLeft.moduleSize + topRight.moduleSize) / 3
+	between := (distance(topLeft.point, topRight.point) + distance(topLeft.point, bottomLeft.point)) / 2
+	// Sizes are 4k+1, start from the nearest one to the estimate.
+	estimate := int(math.Round((between/moduleSize+6)/4))*4 + 1
+	var sizes []int
+	for _, delta := range []int{0, -4, 4, -8, 8} {
+		if size := estimate + delta; size >= minSize && size <= maxSize {
+			sizes = append(sizes, size)
+		}
+	}
+	grids := make([]Grid, 0, len(sizes))
+	for _, size := range sizes {
+		grids = append(grids, sample(matrix, bottomLeft.point, topLeft.point, topRight.point, moduleSize, size))
+	}
+	return grids, nil
+}
+
+// findFinders scans the rows for the 1:1:3:1:1 runs of a finder pattern and
+// confirms each hit along the column and the row through its center.
+func findFinders(matrix *bitmap) []*finder {
+	var finders []*finder
+	step := max(1, matrix.height/maxScanRows)
+	for y := 0; y < matrix.height; y += step {
+		var counts [5]int
+		state := 0
+		for x := 0; x <= matrix.width; x++ {
+			dark := x < matrix.width && matrix.at(x, y)
+			if dark {
+				if state&1 == 1 {
+					state++
+				}
+				counts[state]++
+				continue
+			}
+			if state&1 == 1 {
+				counts[state]++
+				continue
+			}
+			if state != 4 {
+				state++
+				counts[state]++
+				continue
+			}
+			if isFinderRun(counts) {
+				finders = confirmFinder(matrix, finders, counts, x, y)
+				counts, state = [5]int{}, 0
+			} else {
+				counts, state = [5]int{counts[2], counts[3], counts[4], 1, 0}, 3
+			}
+		}
+	}
+	return finders
+}
+
+func isFinderRun(counts [5]int) bool {
+	total := 0
+	for _, count := range counts {
+		if count == 0 {
+			return false
+		}
+		total += count
+	}
+	if total < 7 {
+		return false
+	}
+	module := float64(total) / 7
+	variance := module / 2
+	return math.Abs(module-float64(counts[0])) < variance &&
+		math.Abs(module-float64(counts[1])) < variance &&
+		math.Abs(3*module-float64(counts[2])) < 3*variance &&
+		math.Abs(module-float64(counts[3])) < variance &&
+		math.Abs(module-float64(counts[4])) < variance
+}
+
+func confirmFinder(matrix *bitmap, finders []*finder, counts [5]int, end, y int) []*finder {
+	total := counts[0] + counts[1] + counts[2] + counts[3] + counts[4]
+	centerX := float64(end-counts[4]-counts[3]) - float64(counts[2])/2
+	centerY, ok := crossCheck(matrix, int(centerX), y, 0, 1, counts[2], total)
+	if !ok {
+		return finders
+	}
+	if centerX, ok = crossCheck(matrix, int(centerX), int(centerY), 1, 0, counts[2], total); !ok {
+		return finders
+	}
+	moduleSize := float64(total) / 7
+	for _, f := range finders {
+		if math.Abs(f.x-centerX) <= moduleSize && math.Abs(f.y-centerY) <= moduleSize &&
+			math.Abs(f.moduleSize-moduleSize) <= max(1, f.moduleSize) {
+			weight := float64(f.count)
+			f.x = (f.x*weight + centerX) / (weight + 1)
+			f.y = (f.y*weight + centerY) / (weight + 1)
+			f.moduleSize = (f.moduleSize*weight + moduleSize) / (weight + 1)
+			f.count++
+			return finders
+		}
+	}
+	return append(finders, &finder{point: point{centerX, centerY}, moduleSize: moduleSize, count: 1})
+}
+
+// crossCheck measures the runs through (x, y) along the direction (dx, dy)
+// and returns the center of the pattern along it.
+func crossCheck(matrix *bitmap, x, y, dx, dy, maxCount, total int) (float64, bool) {
+	var counts [5]int
+	walk := func(i, sign int, states [3]int) (int, bool) {
+		for k, state := range states {
+			dark := k%2 == 0
+			for matrix.inside(x+dx*i, y+dy*i) && matrix.at(x+dx*i, y+dy*i) == dark {
+				if state != 2 && counts[state] > maxCount {
+					return i, false
+				}
+				counts[state]++
+				i += sign
+			}
+			if !matrix.inside(x+dx*i, y+dy*i) && k < 2 {
+				return i, false
+			}
+		}
+		return i, true
+	}
+	if _, ok := walk(0, -1, [3]int{2, 1, 0}); !ok {
+		return 0, false
+	}
+	end, ok := walk(1, 1, [3]int{2, 3, 4})
+	if !ok || counts[1] > maxCount || counts[0] > maxCount || counts[3] > maxCount || counts[4] > maxCount {
+		return 0, false
+	}
+	sum := counts[0] + counts[1] + counts[2] + counts[3] + counts[4]
+	if 5*abs(sum-total) >= 2*total || !isFinderRun(counts) {
+		return 0, false
+	}
+	start := x*dx + y*dy
+	return float64(start+end-counts[4]-counts[3]) - float64(counts[2])/2, true
+}
+
+func abs(v int) int {
+	return max(v, -v)
+}
+
+// selectFinders picks the three candidates of the same module size forming
+// the closest to a right isosceles triangle, and orders them.
+func selectFinders(finders []*finder) (*finder, *finder, *finder, error) {
+	confirmed := slices.DeleteFunc(slices.Clone(finders), func(f *finder) bool { return f.count < 2 })
+	if len(confirmed) >= 3 {
+		finders = confirmed
+	}
+	if len(finders) < 3 {
+		return nil, nil, nil, NotFoundError{v: len(finders)}
+	}
+	slices.SortStableFunc(finders, func(a, b *finder) int { return b.count - a.count })
+	finders = finders[:min(len(finders), maxCandidates)]
+	best, bestScore := [3]*finder{}, math.Inf(1)
+	for i := range finders {
+		for j := i + 1; j < len(finders); j++ {
+			for k := j + 1; k < len(finders); k++ {
+				a, b, c := finders[i], finders[j], finders[k]
+				sizes := []float64{a.moduleSize, b.moduleSize, c.moduleSize}
+				if slices.Max(sizes) > 1.4*slices.Min(sizes) {
+					continue
+				}
+				sides := []float64{distance(a.point, b.point), distance(b.point, c.point), distance(a.point, c.point)}
+				slices.Sort(sides)
+				if sides[0] < 7*slices.Min(sizes) {
+					continue
+				}
+				score := math.Abs(sides[2]*sides[2]-sides[0]*sides[0]-sides[1]*sides[1])/(sides[2]*sides[2]) +
+					math.Abs(sides[1]-sides[0])/sides[1]
+				if score < bestScore {
+					best, bestScore = [3]*finder{a, b, c}, score
+				}
+			}
+		}
+	}
+	if best[0] == nil {
+		return nil, nil, nil, NotFoundError{v: 0}
+	}
+	// The top left is opposite to the longest side.
+	a, b, c := best[0], best[1], best[2]
+	ab, bc, ac := distance(a.point, b.point), distance(b.point, c.point), distance(a.point, c.point)
+	switch {
+	case bc >= ab && bc >= ac:
+		a, b = b, a
+	case ab >= ac && ab >= bc:
+		b, c = c, b
+	}
+	// Seen from the top left, the top right comes clockwise of the bottom left.
+	if (c.x-b.x)*(a.y-b.y)-(c.y-b.y)*(a.x-b.x) < 0 {
+		a, c = c, a
+	}
+	return a, b, c, nil
+}
+
+// sample maps the modules of a code of the given size onto the image, the
+// fourth corner comes from the alignment pattern when there is one.
+func sample(matrix *bitmap, bottomLeft, topLeft, topRight point, moduleSize float64, size int) Grid {
+	last := float64(size) - 3.5
+	bottomRight := point{topRight.x - topLeft.x + bottomLeft.x, topRight.y - topLeft.y + bottomLeft.y}
+	src := [4]point{{3.5, 3.5}, {last, 3.5}, {3.5, last}, {last, last}}
+	dst := [4]point{topLeft, topRight, bottomLeft, bottomRight}
+	if size > minSize {
+		// The alignment pattern nearest the bottom right corner sits three
+		// modules in from the corner finder centers.
+		affine := newTransform(src, dst)
+		target := point{last - 3, last - 3}
+		if found, ok := findAlignment(matrix, affine, target, moduleSize); ok {
+			src[3], dst[3] = target, found
+		}
+	}
+	transform := newTransform(src, dst)
+	grid := make(Grid, size)
+	for y := range grid {
+		grid[y] = make([]bool, size)
+		for x := range grid[y] {
+			p := transform.apply(point{float64(x) + 0.5, float64(y) + 0.5})
+			grid[y][x] = matrix.at(int(p.x), int(p.y))
+		}
+	}
+	return grid
+}
+
+// findAlignment searches around the expected center for the 5x5 alignment
+// pattern, scoring each position by the modules matching the pattern.
+func findAlignment(matrix *bitmap, t *transform, target point, moduleSize float64) (point, bool) {
+	center := t.apply(target)
+	radius := int(alignmentRadius * moduleSize)
+	best, bestScore := center, 0
+	for oy := -radius; oy <= radius; oy++ {
+		for ox := -radius; ox <= radius; ox++ {
+			offset := point{float64(ox), float64(oy)}
+			score := 0
+			for my := -2; my <= 2; my++ {
+				for mx := -2; mx <= 2; mx++ {
+					p := t.apply(point{target.x + float64(mx), target.y + float64(my)})
+					dark := max(abs(mx), abs(my)) != 1
+					if matrix.at(int(p.x+offset.x), int(p.y+offset.y)) == dark {
+						score++
+					}
+				}
+			}
+			// Prefer the position closest to the estimate among the ties.
+			if score > bestScore || (score == bestScore && ox*ox+oy*oy < sq(best, center)) {
+				best, bestScore = point{center.x + offset.x, center.y + offset.y}, score
+			}
+		}
+	}
+	return best, bestScore >= minAlignScore
+}
+
+func sq(a, b point) int {
+	dx, dy := a.x-b.x, a.y-b.y
+	return int(dx*dx + dy*dy)
+}
+
+// transform is the perspective mapping of the plane taking four points onto
+// four others.
+type transform struct{ m [8]float64 }
+
+func newTransform(src, dst [4]point) *transform {
+	var a [8][9]float64
+	for i := range 4 {
+		s, d := src[i], dst[i]
+		a[2*i] = [9]float64{s.x, s.y, 1, 0, 0, 0, -s.x * d.x, -s.y * d.x, d.x}
+		a[2*i+1] = [9]float64{0, 0, 0, s.x, s.y, 1, -s.x * d.y, -s.y * d.y, d.y}
+	}
+	// Gaussian elimination with partial pivoting.
+	for col := range 8 {
+		pivot := col
+		for row := col + 1; row < 8; row++ {
+			if math.Abs(a[row][col]) > math.Abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		if a[col][col] == 0 {
+			continue
+		}
+		for row := range 8 {
+			if row == col {
+				continue
+			}
+			factor := a[row][col] / a[col][col]
+			for k := col; k < 9; k++ {
+				a[row][k] -= factor * a[col][k]
+			}
+		}
+	}
+	t := &transform{}
+	for i := range 8 {
+		if a[i][i] != 0 {
+			t.m[i] = a[i][8] / a[i][i]
+		}
+	}
+	return t
+}
+
+func (t *transform) apply(p point) point {
+	m := t.m
+	w := m[6]*p.x + m[7]*p.y + 1
+	return point{(m[0]*p.x + m[1]*p.y + m[2]) / w, (m[3]*p.x + m[4]*p.y + m[5]) / w}
+}
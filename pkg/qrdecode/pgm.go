@@ -0,0 +1,118 @@
+package qrdecode
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"strconv"
+)
+
+type InvalidPGMError struct{ v string }
+
+func (e InvalidPGMError) Error() string {
+	return fmt.Sprintf("qrdecode: invalid pgm(%v)", e.v)
+}
+
+func init() {
+	image.RegisterFormat("pgm", "P5", DecodePGM, DecodePGMConfig)
+	image.RegisterFormat("pgm", "P2", DecodePGM, DecodePGMConfig)
+}
+
+// DecodePGM reads a binary (P5) or plain (P2) portable graymap, which is
+// what most scanning tools write without any extra dependency.
+func DecodePGM(r io.Reader) (image.Image, error) {
+	br := bufio.NewReader(r)
+	plain, width, height, maxValue, err := readPGMHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		var value int
+		switch {
+		case plain:
+			value, err = readPGMNumber(br)
+		case maxValue > 0xff:
+			var hi, lo byte
+			if hi, err = br.ReadByte(); err == nil {
+				lo, err = br.ReadByte()
+			}
+			value = int(hi)<<8 | int(lo)
+		default:
+			var b byte
+			b, err = br.ReadByte()
+			value = int(b)
+		}
+		if err != nil {
+			return nil, InvalidPGMError{v: "truncated pixels"}
+		}
+		if value > maxValue {
+			return nil, InvalidPGMError{v: "pixel out of range"}
+		}
+		img.Pix[i] = byte(value * 0xff / maxValue)
+	}
+	return img, nil
+}
+
+// DecodePGMConfig returns the size of a portable graymap.
+func DecodePGMConfig(r io.Reader) (image.Config, error) {
+	_, width, height, _, err := readPGMHeader(bufio.NewReader(r))
+	if err != nil {
+		return image.Config{}, err
+	}
+	return image.Config{ColorModel: color.GrayModel, Width: width, Height: height}, nil
+}
+
+func readPGMHeader(br *bufio.Reader) (bool, int, int, int, error) {
+	magic := make([]byte, 2)
+	if _, err := io.ReadFull(br, magic); err != nil || (string(magic) != "P5" && string(magic) != "P2") {
+		return false, 0, 0, 0, InvalidPGMError{v: "magic"}
+	}
+	var values [3]int
+	for i := range values {
+		value, err := readPGMNumber(br)
+		if err != nil || value <= 0 {
+			return false, 0, 0, 0, InvalidPGMError{v: "header"}
+		}
+		values[i] = value
+	}
+	width, height, maxValue := values[0], values[1], values[2]
+	if maxValue > 0xffff || width > 1<<14 || height > 1<<14 {
+		return false, 0, 0, 0, InvalidPGMError{v: "header"}
+	}
+	return string(magic) == "P2", width, height, maxValue, nil
+}
+
+// readPGMNumber reads a decimal number, skipping the whitespace and comments
+// before it and consuming the single whitespace after it.
+func readPGMNumber(br *bufio.Reader) (int, error) {
+	var digits []byte
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			if err == io.EOF && len(digits) != 0 {
+				break
+			}
+			return 0, err
+		}
+		switch {
+		case b >= '0' && b <= '9':
+			digits = append(digits, b)
+			continue
+		case len(digits) != 0:
+		case b == '#':
+			if _, err := br.ReadBytes('\n'); err != nil {
+				return 0, err
+			}
+			continue
+		case b == ' ' || b == '\t' || b == '\n' || b == '\r':
+			continue
+		default:
+			return 0, InvalidPGMError{v: "number"}
+		}
+		break
+	}
+	return strconv.Atoi(string(digits))
+}
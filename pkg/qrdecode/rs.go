@@ -0,0 +1,114 @@
+package qrdecode
+
+import (
+	"rsc.io/qr/coding"
+)
+
+// correct fixes up to half as many errors as there are check bytes at the
+// end of a block, the check bytes are those of a Reed-Solomon code over
+// GF(2^8) whose generator has the roots α^0, α^1, ...
+func correct(block []byte, check int) error {
+	field := coding.Field
+	n := len(block)
+	syndromes := make([]byte, check)
+	clean := true
+	for i := range syndromes {
+		x := field.Exp(i)
+		var value byte
+		for _, b := range block {
+			value = field.Mul(value, x) ^ b
+		}
+		syndromes[i] = value
+		clean = clean && value == 0
+	}
+	if clean {
+		return nil
+	}
+
+	// Berlekamp-Massey finds the error locator, with the coefficients from
+	// the lowest degree.
+	locator, prev := []byte{1}, []byte{1}
+	size, shift, scale := 0, 1, byte(1)
+	for i := range check {
+		delta := syndromes[i]
+		for j := 1; j <= size && j < len(locator); j++ {
+			delta ^= field.Mul(locator[j], syndromes[i-j])
+		}
+		if delta == 0 {
+			shift++
+			continue
+		}
+		next := addScaled(locator, prev, field.Mul(delta, field.Inv(scale)), shift)
+		if 2*size <= i {
+			prev, size, scale, shift = locator, i+1-size, delta, 1
+		} else {
+			shift++
+		}
+		locator = next
+	}
+	if 2*size > check {
+		return UncorrectableError{v: size}
+	}
+
+	// The evaluator is the product of the syndromes and the locator modulo
+	// x^check, Forney then gives the magnitude of each error.
+	evaluator := make([]byte, check)
+	for i := range evaluator {
+		for j := 0; j <= i && j < len(locator); j++ {
+			evaluator[i] ^= field.Mul(locator[j], syndromes[i-j])
+		}
+	}
+	found := 0
+	for i := range 255 {
+		xInv := field.Exp(i)
+		if evaluate(locator, xInv) != 0 {
+			continue
+		}
+		// The root α^i locates the error at the power 255-i.
+		power := (255 - i) % 255
+		position := n - 1 - power
+		if position < 0 {
+			return UncorrectableError{v: size}
+		}
+		var derivative byte
+		for j := 1; j < len(locator); j += 2 {
+			derivative ^= field.Mul(locator[j], pow(xInv, j-1))
+		}
+		if derivative == 0 {
+			return UncorrectableError{v: size}
+		}
+		magnitude := field.Mul(field.Mul(field.Exp(power), evaluate(evaluator, xInv)), field.Inv(derivative))
+		block[position] ^= magnitude
+		found++
+	}
+	if found != size {
+		return UncorrectableError{v: size}
+	}
+	return nil
+}
+
+// addScaled returns a + scale * x^shift * b.
+func addScaled(a, b []byte, scale byte, shift int) []byte {
+	result := make([]byte, max(len(a), len(b)+shift))
+	copy(result, a)
+	for i, c := range b {
+		result[i+shift] ^= coding.Field.Mul(c, scale)
+	}
+	return result
+}
+
+func evaluate(poly []byte, x byte) byte {
+	var value byte
+	for i := len(poly) - 1; i >= 0; i-- {
+		value = coding.Field.Mul(value, x) ^ poly[i]
+	}
+	return value
+}
+
+func pow(x byte, e int) byte {
+	result := byte(1)
+	for range e {
+		result = coding.Field.Mul(result, x)
+	}
+	return result
+}
@@ -0,0 +1,119 @@
+package qrencode
+
+import (
+	"fmt"
+	"strings"
+
+	"rsc.io/qr"
+	"rsc.io/qr/coding"
+)
+
+const (
+	modeByte = iota
+	modeAlpha
+	modeNum
+)
+
+const (
+	scale    = 8
+	alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+)
+
+type TooLongError struct{ v int }
+
+func (e TooLongError) Error() string {
+	return fmt.Sprintf("qrencode: text too long(%v bytes)", e.v)
+}
+
+// classes are the ranges of versions sharing the widths of the character
+// counts, a segmentation is optimal for all versions of a class.
+var classes = [][2]coding.Version{{1, 9}, {10, 26}, {27, 40}}
+
+// Encode is qr.Encode except that the text is split into numeric,
+// alphanumeric and byte segments, so that text mixing them such as an
+// uppercase address with a lowercase label fits the smallest version.
+func Encode(text string, level qr.Level) (*qr.Code, error) {
+	l := coding.Level(level)
+	for _, class := range classes {
+		segments, bits := Segment(text, class[0])
+		for v := class[0]; v <= class[1]; v++ {
+			if bits > v.DataBytes(l)*8 {
+				continue
+			}
+			plan, err := coding.NewPlan(v, l, 0)
+			if err != nil {
+				return nil, fmt.Errorf("qrencode: %w", err)
+			}
+			code, err := plan.Encode(segments...)
+			if err != nil {
+				return nil, fmt.Errorf("qrencode: %w", err)
+			}
+			return &qr.Code{Bitmap: code.Bitmap, Size: code.Size, Stride: code.Stride, Scale: scale}, nil
+		}
+	}
+	return nil, TooLongError{v: len(text)}
+}
+
+// Segment returns the segments taking the fewest bits in the version along
+// with their number of bits. A segment too long for its character count
+// would not fit any version of the class anyway.
+func Segment(text string, version coding.Version) ([]coding.Encoding, int) {
+	n := len(text)
+	// The ends of the runs of digits and alphanumeric characters starting at
+	// each position.
+	numEnd, alphaEnd := make([]int, n+1), make([]int, n+1)
+	numEnd[n], alphaEnd[n] = n, n
+	for i := n - 1; i >= 0; i-- {
+		numEnd[i], alphaEnd[i] = i, i
+		if text[i] >= '0' && text[i] <= '9' {
+			numEnd[i] = numEnd[i+1]
+		}
+		if strings.IndexByte(alphabet, text[i]) >= 0 {
+			alphaEnd[i] = alphaEnd[i+1]
+		}
+	}
+	header := [3]int{modeByte: coding.String("").Bits(version), modeAlpha: coding.Alpha("").Bits(version),
+		modeNum: coding.Num("").Bits(version)}
+	best, mode, from := make([]int, n+1), make([]int, n+1), make([]int, n+1)
+	for i := 1; i <= n; i++ {
+		best[i] = -1
+		for j := range i {
+			for m := modeByte; m <= modeNum; m++ {
+				if (m == modeAlpha && alphaEnd[j] < i) || (m == modeNum && numEnd[j] < i) {
+					break
+				}
+				if bits := best[j] + header[m] + dataBits(m, i-j); best[i] < 0 || bits < best[i] {
+					best[i], mode[i], from[i] = bits, m, j
+				}
+			}
+		}
+	}
+	var segments []coding.Encoding
+	for i := n; i > 0; i = from[i] {
+		switch segment := text[from[i]:i]; mode[i] {
+		case modeNum:
+			segments = append(segments, coding.Num(segment))
+		case modeAlpha:
+			segments = append(segments, coding.Alpha(segment))
+		default:
+			segments = append(segments, coding.String(segment))
+		}
+	}
+	for i, j := 0, len(segments)-1; i < j; i, j = i+1, j-1 {
+		segments[i], segments[j] = segments[j], segments[i]
+	}
+	return segments, best[n]
+}
+
+// dataBits is the number of bits of count characters after the header, as
+// computed by the Bits methods of coding.
+func dataBits(mode, count int) int {
+	switch mode {
+	case modeNum:
+		return (10*count + 2) / 3
+	case modeAlpha:
+		return (11*count + 1) / 2
+	default:
+		return 8 * count
+	}
+}
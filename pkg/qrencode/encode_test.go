@@ -0,0 +1,77 @@
+package qrencode_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/rbee3u/dpass/pkg/qrdecode"
+	"github.com/rbee3u/dpass/pkg/qrencode"
+	"rsc.io/qr"
+	"rsc.io/qr/coding"
+)
+
+func TestSegment(t *testing.T) {
+	tests := []struct {
+		text     string
+		segments string
+	}{
+		{text: "", segments: "[]"},
+		{text: "0123456789", segments: "[Num(`0123456789`)]"},
+		{text: "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", segments: "[Alpha(`BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4`)]"},
+		{text: "id 0123456789012345", segments: "[String(`id `) Num(`0123456789012345`)]"},
+		// Short runs of digits are cheaper left in the surrounding segment.
+		{text: "abc12def", segments: "[String(`abc12def`)]"},
+		{text: "ABC12DEF", segments: "[Alpha(`ABC12DEF`)]"},
+	}
+	for _, tt := range tests {
+		segments, _ := qrencode.Segment(tt.text, 1)
+		if got := fmt.Sprint(segments); got != tt.segments {
+			t.Errorf("got = %s, want = %s", got, tt.segments)
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		text string
+		size int
+	}{
+		{text: "bitcoin:BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4?amount=0.01", size: 29},
+		{text: "seed 011513251154012711900771041507421289190620080870026613431420201617920614089619290300152408010643", size: 29},
+		{text: strings.Repeat("-----BEGIN SHAMIR-----\nI: 1\n\nq83vEjRWeJ\n", 40), size: 129},
+	}
+	for _, tt := range tests {
+		code, err := qrencode.Encode(tt.text, qr.L)
+		if err != nil {
+			t.Fatalf("failed to encode: %v", err)
+		}
+		plain, err := qr.Encode(tt.text, qr.L)
+		if err != nil {
+			t.Fatalf("failed to encode: %v", err)
+		}
+		if code.Size != tt.size || code.Size > plain.Size {
+			t.Errorf("got = %v, want = %v (qr.Encode %v)", code.Size, tt.size, plain.Size)
+		}
+		grid := make(qrdecode.Grid, code.Size)
+		for y := range grid {
+			grid[y] = make([]bool, code.Size)
+			for x := range grid[y] {
+				grid[y][x] = code.Black(x, y)
+			}
+		}
+		payload, err := qrdecode.DecodeGrid(grid)
+		if err != nil {
+			t.Fatalf("failed to decode: %v", err)
+		}
+		if string(payload) != tt.text {
+			t.Errorf("got = %q, want = %q", payload, tt.text)
+		}
+	}
+	if _, err := qrencode.Encode(strings.Repeat("x", 3000), qr.L); err == nil {
+		t.Errorf("got = nil, want = error")
+	}
+	if _, bits := qrencode.Segment(strings.Repeat("7", 30), coding.Version(10)); bits != 4+12+100 {
+		t.Errorf("got = %v, want = %v", bits, 4+12+100)
+	}
+}
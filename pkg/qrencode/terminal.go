@@ -0,0 +1,83 @@
+package qrencode
+
+import (
+	"rsc.io/qr"
+)
+
+// Terminal draws codes on a terminal, with ANSI background colors or with
+// block characters when NoColor is set. Quiet is the width of the quiet zone
+// in modules, Swap exchanges dark and light for dark themes.
+type Terminal struct {
+	Quiet   int
+	Swap    bool
+	NoColor bool
+}
+
+// black tells whether a module of the code with its quiet zone is drawn as
+// dark, taking swap into account.
+func (t Terminal) black(code *qr.Code, x, y int) bool {
+	return code.Black(x-t.Quiet, y-t.Quiet) != t.Swap
+}
+
+// Draw draws each module with two characters, which keeps it about square.
+func (t Terminal) Draw(code *qr.Code) []byte {
+	var data []byte
+	for y := range t.Quiet + code.Size + t.Quiet {
+		for x := range t.Quiet + code.Size + t.Quiet {
+			switch black := t.black(code, x, y); {
+			case t.NoColor && black:
+				data = append(data, "██"...)
+			case t.NoColor:
+				data = append(data, "  "...)
+			case black:
+				data = append(data, "\u001B[40m  "...)
+			default:
+				data = append(data, "\u001B[47m  "...)
+			}
+		}
+		if t.NoColor {
+			data = append(data, '\n')
+		} else {
+			data = append(data, "\u001B[0m\n"...)
+		}
+	}
+	return data
+}
+
+// DrawHalf puts two rows of modules on each line with the upper half block,
+// which halves the height of the code on the terminal. A missing row at the
+// bottom is drawn like the quiet zone, which is dark when swapped.
+func (t Terminal) DrawHalf(code *qr.Code) []byte {
+	var data []byte
+	size := t.Quiet + code.Size + t.Quiet
+	for y := 0; y < size; y += 2 {
+		for x := range size {
+			upper, lower := t.black(code, x, y), t.black(code, x, y+1)
+			if t.NoColor {
+				data = append(data, halfBlocks[upper][lower]...)
+				continue
+			}
+			// The foreground paints the upper half and the background the lower half.
+			foreground, background := "37", "47"
+			if upper {
+				foreground = "30"
+			}
+			if lower {
+				background = "40"
+			}
+			data = append(data, "\u001B["+foreground+";"+background+"m▀"...)
+		}
+		if t.NoColor {
+			data = append(data, '\n')
+		} else {
+			data = append(data, "\u001B[0m\n"...)
+		}
+	}
+	return data
+}
+
+// halfBlocks are indexed by whether the upper and the lower modules are dark.
+var halfBlocks = map[bool]map[bool]string{
+	true:  {true: "█", false: "▀"},
+	false: {true: "▄", false: " "},
+}
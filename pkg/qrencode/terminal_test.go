@@ -0,0 +1,65 @@
+package qrencode_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rbee3u/dpass/pkg/qrencode"
+	"rsc.io/qr"
+)
+
+func TestTerminal(t *testing.T) {
+	code, err := qr.Encode("To be, or not to be, that is the question.", qr.H)
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	terminal := qrencode.Terminal{Quiet: 2, NoColor: true}
+	halfBlocks := map[bool]map[bool]string{
+		true:  {true: "█", false: "▀"},
+		false: {true: "▄", false: " "},
+	}
+	data := terminal.DrawHalf(code)
+	size := terminal.Quiet + code.Size + terminal.Quiet
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != (size+1)/2 || strings.Contains(string(data), "\u001B") {
+		t.Fatalf("got half:\n%s", data)
+	}
+	black := func(x, y int) bool {
+		return code.Black(x-terminal.Quiet, y-terminal.Quiet)
+	}
+	for y, line := range lines {
+		runes := []rune(line)
+		if len(runes) != size {
+			t.Fatalf("line %v got = %v, want = %v", y, len(runes), size)
+		}
+		for x, r := range runes {
+			upper, lower := black(x, 2*y), 2*y+1 < size && black(x, 2*y+1)
+			if want := halfBlocks[upper][lower]; string(r) != want {
+				t.Fatalf("module (%v, %v) got = %q, want = %q", x, 2*y, r, want)
+			}
+		}
+	}
+
+	// With an odd size the missing bottom row takes the swapped quiet zone.
+	terminal.Swap = true
+	data = terminal.DrawHalf(code)
+	lines = strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if size%2 != 1 || lines[len(lines)-1] != strings.Repeat(halfBlocks[true][true], size) {
+		t.Fatalf("got half:\n%s", data)
+	}
+	terminal.Swap = false
+
+	terminal.NoColor = false
+	if data = terminal.DrawHalf(code); strings.Count(string(data), "▀") != size*((size+1)/2) {
+		t.Fatalf("got half:\n%s", data)
+	}
+
+	terminal.NoColor = true
+	data = terminal.Draw(code)
+	if strings.Contains(string(data), "\u001B") || strings.Count(string(data), "\n") != size {
+		t.Fatalf("got ansi:\n%s", data)
+	}
+	if got, want := strings.Count(string(data), "█"), 0; got == want {
+		t.Errorf("got = %v, want > %v", got, want)
+	}
+}
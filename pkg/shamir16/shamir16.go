@@ -0,0 +1,217 @@
+package shamir16
+
+import (
+	"fmt"
+	"io"
+)
+
+// Shares are computed over GF(2^16) reduced by x^16 + x^12 + x^3 + x + 1, so
+// that up to 65535 shares can be issued. Each byte of the secret is lifted to
+// an element of the field, and every element of a share is stored in two bytes
+// big endian. The last element of a share is its x coordinate, which is always
+// the one based index of the share.
+const (
+	MinThreshold  = 2
+	MaxParts      = 65535
+	ShareOverhead = 2
+
+	reduction = 0x100b
+)
+
+type InvalidPartsError struct{ v int }
+
+func (e InvalidPartsError) Error() string {
+	return fmt.Sprintf("shamir16: invalid parts(%v)", e.v)
+}
+
+type InvalidThresholdError struct{ v int }
+
+func (e InvalidThresholdError) Error() string {
+	return fmt.Sprintf("shamir16: invalid threshold(%v)", e.v)
+}
+
+type InvalidSecretError struct{ v int }
+
+func (e InvalidSecretError) Error() string {
+	return fmt.Sprintf("shamir16: invalid secret length(%v)", e.v)
+}
+
+type InvalidShareError struct{ v int }
+
+func (e InvalidShareError) Error() string {
+	return fmt.Sprintf("shamir16: invalid share length(%v)", e.v)
+}
+
+type InvalidCoordinateError struct{ v uint16 }
+
+func (e InvalidCoordinateError) Error() string {
+	return fmt.Sprintf("shamir16: invalid coordinate(%v)", e.v)
+}
+
+type CorruptedShareError struct{}
+
+func (e CorruptedShareError) Error() string {
+	return "shamir16: corrupted share"
+}
+
+func Split(random io.Reader, secret []byte, parts, threshold int) ([][]byte, error) {
+	if parts < MinThreshold || parts > MaxParts {
+		return nil, InvalidPartsError{v: parts}
+	}
+	if threshold < MinThreshold || threshold > parts {
+		return nil, InvalidThresholdError{v: threshold}
+	}
+	if len(secret) == 0 {
+		return nil, InvalidSecretError{v: len(secret)}
+	}
+	shares := make([][]byte, parts)
+	for i := range shares {
+		shares[i] = make([]byte, 2*len(secret)+ShareOverhead)
+		putElement(shares[i], len(secret), uint16(i+1))
+	}
+	coefficients := make([]uint16, threshold)
+	buffer := make([]byte, 2*(threshold-1))
+	for i := range secret {
+		if _, err := io.ReadFull(random, buffer); err != nil {
+			return nil, fmt.Errorf("shamir16: failed to read random: %w", err)
+		}
+		coefficients[0] = uint16(secret[i])
+		for j := 1; j < threshold; j++ {
+			coefficients[j] = getElement(buffer, j-1)
+		}
+		for j := range shares {
+			putElement(shares[j], i, evaluate(coefficients, uint16(j+1)))
+		}
+	}
+	return shares, nil
+}
+
+func Combine(shares [][]byte) ([]byte, error) {
+	xs, err := coordinates(shares)
+	if err != nil {
+		return nil, err
+	}
+	secret := make([]byte, len(shares[0])/2-1)
+	ys := make([]uint16, len(shares))
+	for i := range secret {
+		for j := range shares {
+			ys[j] = getElement(shares[j], i)
+		}
+		value := interpolate(xs, ys, 0)
+		if value > 0xff {
+			return nil, CorruptedShareError{}
+		}
+		secret[i] = byte(value)
+	}
+	return secret, nil
+}
+
+// Evaluate issues the share at coordinate x of the same polynomials, at least
+// threshold shares must be supplied or the result is meaningless.
+func Evaluate(shares [][]byte, x uint16) ([]byte, error) {
+	xs, err := coordinates(shares)
+	if err != nil {
+		return nil, err
+	}
+	if x == 0 {
+		return nil, InvalidCoordinateError{v: x}
+	}
+	for i := range xs {
+		if xs[i] == x {
+			return nil, InvalidCoordinateError{v: x}
+		}
+	}
+	share := make([]byte, len(shares[0]))
+	elements := len(share)/2 - 1
+	putElement(share, elements, x)
+	ys := make([]uint16, len(shares))
+	for i := range elements {
+		for j := range shares {
+			ys[j] = getElement(shares[j], i)
+		}
+		putElement(share, i, interpolate(xs, ys, x))
+	}
+	return share, nil
+}
+
+// Coordinate returns the x coordinate of a share.
+func Coordinate(share []byte) uint16 {
+	return getElement(share, len(share)/2-1)
+}
+
+func coordinates(shares [][]byte) ([]uint16, error) {
+	if len(shares) < MinThreshold {
+		return nil, InvalidThresholdError{v: len(shares)}
+	}
+	size := len(shares[0])
+	xs := make([]uint16, len(shares))
+	seen := make(map[uint16]bool, len(shares))
+	for i := range shares {
+		if len(shares[i]) != size || size%2 != 0 || size < 2+ShareOverhead {
+			return nil, InvalidShareError{v: len(shares[i])}
+		}
+		xs[i] = Coordinate(shares[i])
+		if xs[i] == 0 || seen[xs[i]] {
+			return nil, InvalidCoordinateError{v: xs[i]}
+		}
+		seen[xs[i]] = true
+	}
+	return xs, nil
+}
+
+func getElement(data []byte, i int) uint16 {
+	return uint16(data[2*i])<<8 | uint16(data[2*i+1])
+}
+
+func putElement(data []byte, i int, v uint16) {
+	data[2*i], data[2*i+1] = byte(v>>8), byte(v)
+}
+
+// evaluate computes the polynomial at x by Horner's method.
+func evaluate(coefficients []uint16, x uint16) uint16 {
+	out := coefficients[len(coefficients)-1]
+	for i := len(coefficients) - 2; i >= 0; i-- {
+		out = mul(out, x) ^ coefficients[i]
+	}
+	return out
+}
+
+// interpolate computes the value at x of the polynomial passing through the
+// samples by Lagrange interpolation.
+func interpolate(xs, ys []uint16, x uint16) uint16 {
+	var result uint16
+	for i := range xs {
+		numerator, denominator := uint16(1), uint16(1)
+		for j := range xs {
+			if i != j {
+				numerator = mul(numerator, x^xs[j])
+				denominator = mul(denominator, xs[i]^xs[j])
+			}
+		}
+		result ^= mul(ys[i], mul(numerator, inv(denominator)))
+	}
+	return result
+}
+
+// mul multiplies in GF(2^16) without any data dependent branch.
+func mul(a, b uint16) uint16 {
+	var r uint16
+	for i := 15; i >= 0; i-- {
+		r = (-(r >> 15) & reduction) ^ (r << 1)
+		r ^= -((b >> i) & 1) & a
+	}
+	return r
+}
+
+// inv computes a^(2^16-2) which is the inverse of a, and zero for zero. The
+// branch only depends on the public exponent.
+func inv(a uint16) uint16 {
+	r := uint16(1)
+	for i := 15; i >= 0; i-- {
+		r = mul(r, r)
+		if (0xfffe>>i)&1 == 1 {
+			r = mul(r, a)
+		}
+	}
+	return r
+}
@@ -0,0 +1,81 @@
+package shamir16
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestField(t *testing.T) {
+	for a := 1; a <= 0xffff; a++ {
+		if r := mul(uint16(a), inv(uint16(a))); r != 1 {
+			t.Fatalf("got = %v, want = 1 (a = %v)", r, a)
+		}
+	}
+	if r := inv(0); r != 0 {
+		t.Fatalf("got = %v, want = 0", r)
+	}
+	if r := mul(3, 7); r != 9 {
+		t.Fatalf("got = %v, want = 9", r)
+	}
+}
+
+func TestSplitAndCombine(t *testing.T) {
+	secret := []byte("To be, or not to be, that is the question.")
+	shares, err := Split(rand.Reader, secret, 300, 5)
+	if err != nil {
+		t.Fatalf("failed to split: %v", err)
+	}
+	if len(shares) != 300 || len(shares[299]) != 2*len(secret)+ShareOverhead || Coordinate(shares[299]) != 300 {
+		t.Fatalf("got %v shares of length %v", len(shares), len(shares[0]))
+	}
+	for i := 0; i+5 <= len(shares); i += 37 {
+		combined, err := Combine(shares[i : i+5])
+		if err != nil {
+			t.Fatalf("failed to combine: %v", err)
+		}
+		if !bytes.Equal(combined, secret) {
+			t.Fatalf("got = %v, want = %v", combined, secret)
+		}
+	}
+	extra, err := Evaluate(shares[10:15], 301)
+	if err != nil {
+		t.Fatalf("failed to evaluate: %v", err)
+	}
+	combined, err := Combine([][]byte{shares[0], shares[99], shares[199], shares[299], extra})
+	if err != nil {
+		t.Fatalf("failed to combine: %v", err)
+	}
+	if !bytes.Equal(combined, secret) {
+		t.Fatalf("got = %v, want = %v", combined, secret)
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	secret := []byte("test")
+	if _, err := Split(rand.Reader, secret, MaxParts+1, 2); err == nil {
+		t.Fatalf("expect error with too many parts")
+	}
+	if _, err := Split(rand.Reader, secret, 3, 4); err == nil {
+		t.Fatalf("expect error with threshold above parts")
+	}
+	if _, err := Split(rand.Reader, nil, 3, 2); err == nil {
+		t.Fatalf("expect error with empty secret")
+	}
+	if _, err := Split(bytes.NewReader(nil), secret, 3, 2); err == nil {
+		t.Fatalf("expect error without randomness")
+	}
+	shares, err := Split(rand.Reader, secret, 3, 2)
+	if err != nil {
+		t.Fatalf("failed to split: %v", err)
+	}
+	if _, err := Combine([][]byte{shares[0], shares[0]}); err == nil {
+		t.Fatalf("expect error with duplicated shares")
+	}
+	if _, err := Combine([][]byte{shares[0], shares[1][1:]}); err == nil {
+		t.Fatalf("expect error with mismatched length")
+	}
+	if _, err := Evaluate(shares[:2], 2); err == nil {
+		t.Fatalf("expect error with used coordinate")
+	}
+}
@@ -0,0 +1,76 @@
+package ur
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"strings"
+)
+
+// Bytewords encode each byte as one of 256 four-letter words, the minimal
+// style keeps only the first and the last letters of each word.
+const (
+	wordLen      = 4
+	minimalLen   = 2
+	checksumSize = 4
+)
+
+var (
+	words = strings.Fields(
+		"able acid also apex aqua arch atom aunt away axis back bald barn belt beta bias " +
+			"blue body brag brew bulb buzz calm cash cats chef city claw code cola cook cost " +
+			"crux curl cusp cyan dark data days deli dice diet door down draw drop drum dull " +
+			"duty each easy echo edge epic even exam exit eyes fact fair fern figs film fish " +
+			"fizz flap flew flux foxy free frog fuel fund gala game gear gems gift girl glow " +
+			"good gray grim guru gush gyro half hang hard hawk heat help high hill holy hope " +
+			"horn huts iced idea idle inch inky into iris iron item jade jazz join jolt jowl " +
+			"judo jugs jump junk jury keep keno kept keys kick kiln king kite kiwi knob lamb " +
+			"lava lazy leaf legs liar limp lion list logo loud love luau luck lung main many " +
+			"math maze memo menu meow mild mint miss monk nail navy need news next noon note " +
+			"numb obey oboe omit onyx open oval owls paid part peck play plus poem pool pose " +
+			"puff puma purr quad quiz race ramp real redo rich road rock roof ruby ruin runs " +
+			"rust safe saga scar sets silk skew slot soap solo song stub surf swan taco task " +
+			"taxi tent tied time tiny toil tomb toys trip tuna twin ugly undo unit urge user " +
+			"vast very veto vial vibe view visa void vows wall wand warm wasp wave waxy webs " +
+			"what when whiz wolf work yank yawn yell yoga yurt zaps zero zest zinc zone zoom")
+	minimalToByte = generateMinimalToByte()
+)
+
+func generateMinimalToByte() map[string]byte {
+	minimalToByte := make(map[string]byte, len(words))
+	for value, word := range words {
+		minimalToByte[word[:1]+word[wordLen-1:]] = byte(value)
+	}
+	return minimalToByte
+}
+
+// EncodeBytewords encodes the data with its CRC-32 in the minimal style.
+func EncodeBytewords(data []byte) string {
+	data = binary.BigEndian.AppendUint32(append([]byte{}, data...), crc32.ChecksumIEEE(data))
+	var builder strings.Builder
+	for _, b := range data {
+		word := words[b]
+		builder.WriteString(word[:1] + word[wordLen-1:])
+	}
+	return builder.String()
+}
+
+// DecodeBytewords decodes the minimal style and verifies the CRC-32.
+func DecodeBytewords(encoded string) ([]byte, error) {
+	encoded = strings.ToLower(encoded)
+	if len(encoded)%minimalLen != 0 || len(encoded) < minimalLen*checksumSize {
+		return nil, InvalidBytewordsError{v: encoded}
+	}
+	data := make([]byte, 0, len(encoded)/minimalLen)
+	for i := 0; i < len(encoded); i += minimalLen {
+		value, exist := minimalToByte[encoded[i:i+minimalLen]]
+		if !exist {
+			return nil, InvalidBytewordsError{v: encoded[i : i+minimalLen]}
+		}
+		data = append(data, value)
+	}
+	data, checksum := data[:len(data)-checksumSize], data[len(data)-checksumSize:]
+	if binary.BigEndian.Uint32(checksum) != crc32.ChecksumIEEE(data) {
+		return nil, ChecksumMismatchError{v: binary.BigEndian.Uint32(checksum), u: crc32.ChecksumIEEE(data)}
+	}
+	return data, nil
+}
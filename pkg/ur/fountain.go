@@ -0,0 +1,411 @@
+package ur
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"hash/crc32"
+	"math"
+	"math/bits"
+	"slices"
+)
+
+// MinFragmentLen is the smallest fragment a message is split into.
+const MinFragmentLen = 10
+
+// Part is one fragment of a message, or the XOR of several fragments once all
+// of them have been emitted once, which is what makes the code a fountain: any
+// large enough subset of the parts is able to rebuild the message.
+type Part struct {
+	SeqNum     uint32
+	SeqLen     int
+	MessageLen int
+	Checksum   uint32
+	Data       []byte
+}
+
+// Indexes returns the fragments mixed into the part.
+func (p *Part) Indexes() []int {
+	return chooseFragments(p.SeqNum, p.SeqLen, p.Checksum)
+}
+
+type FountainEncoder struct {
+	messageLen int
+	checksum   uint32
+	fragments  [][]byte
+	seqNum     uint32
+}
+
+func NewFountainEncoder(message []byte, maxFragmentLen int) (*FountainEncoder, error) {
+	if len(message) == 0 {
+		return nil, InvalidMessageError{v: len(message)}
+	}
+	if maxFragmentLen < MinFragmentLen {
+		return nil, InvalidFragmentLenError{v: maxFragmentLen}
+	}
+	fragmentLen := nominalFragmentLen(len(message), MinFragmentLen, maxFragmentLen)
+	var fragments [][]byte
+	for i := 0; i < len(message); i += fragmentLen {
+		fragment := make([]byte, fragmentLen)
+		copy(fragment, message[i:min(i+fragmentLen, len(message))])
+		fragments = append(fragments, fragment)
+	}
+	return &FountainEncoder{
+		messageLen: len(message),
+		checksum:   crc32.ChecksumIEEE(message),
+		fragments:  fragments,
+	}, nil
+}
+
+// SeqLen returns the number of fragments, which is also the number of pure
+// parts emitted before the mixed ones.
+func (e *FountainEncoder) SeqLen() int {
+	return len(e.fragments)
+}
+
+func (e *FountainEncoder) NextPart() *Part {
+	e.seqNum++
+	indexes := chooseFragments(e.seqNum, len(e.fragments), e.checksum)
+	data := make([]byte, len(e.fragments[0]))
+	for _, index := range indexes {
+		xorInto(data, e.fragments[index])
+	}
+	return &Part{
+		SeqNum:     e.seqNum,
+		SeqLen:     len(e.fragments),
+		MessageLen: e.messageLen,
+		Checksum:   e.checksum,
+		Data:       data,
+	}
+}
+
+// nominalFragmentLen returns the length of the fewest fragments no longer
+// than maxFragmentLen.
+func nominalFragmentLen(messageLen, minFragmentLen, maxFragmentLen int) int {
+	maxFragmentCount := max(messageLen/minFragmentLen, 1)
+	fragmentLen := messageLen
+	for fragmentCount := 1; fragmentCount <= maxFragmentCount; fragmentCount++ {
+		fragmentLen = (messageLen + fragmentCount - 1) / fragmentCount
+		if fragmentLen <= maxFragmentLen {
+			break
+		}
+	}
+	return fragmentLen
+}
+
+// chooseFragments returns the fragments mixed into a part, the first seqLen
+// parts are the fragments themselves in order.
+func chooseFragments(seqNum uint32, seqLen int, checksum uint32) []int {
+	if int(seqNum) <= seqLen {
+		return []int{int(seqNum) - 1}
+	}
+	seed := binary.BigEndian.AppendUint32(binary.BigEndian.AppendUint32(nil, seqNum), checksum)
+	rng := newXoshiro256(seed)
+	degree := chooseDegree(seqLen, rng)
+	indexes := make([]int, seqLen)
+	for i := range indexes {
+		indexes[i] = i
+	}
+	return shuffled(indexes, rng)[:degree]
+}
+
+// chooseDegree picks the number of fragments mixed into a part, a degree d
+// is chosen with a probability proportional to 1/d.
+func chooseDegree(seqLen int, rng *xoshiro256) int {
+	probs := make([]float64, seqLen)
+	for i := range probs {
+		probs[i] = 1 / float64(i+1)
+	}
+	return newRandomSampler(probs).next(rng.nextDouble) + 1
+}
+
+func shuffled(items []int, rng *xoshiro256) []int {
+	remaining := slices.Clone(items)
+	result := make([]int, 0, len(items))
+	for len(remaining) != 0 {
+		index := rng.nextInt(0, uint64(len(remaining)-1))
+		result = append(result, remaining[index])
+		remaining = slices.Delete(remaining, int(index), int(index)+1)
+	}
+	return result
+}
+
+func xorInto(dst, src []byte) {
+	for i := range dst {
+		dst[i] ^= src[i]
+	}
+}
+
+// xoshiro256 is the xoshiro256** generator seeded with the SHA-256 of the
+// seed, so that encoders and decoders agree on the mixing of every part.
+type xoshiro256 struct{ s [4]uint64 }
+
+func newXoshiro256(seed []byte) *xoshiro256 {
+	digest := sha256.Sum256(seed)
+	rng := &xoshiro256{}
+	for i := range rng.s {
+		rng.s[i] = binary.BigEndian.Uint64(digest[8*i:])
+	}
+	return rng
+}
+
+func (r *xoshiro256) next() uint64 {
+	result := bits.RotateLeft64(r.s[1]*5, 7) * 9
+	t := r.s[1] << 17
+	r.s[2] ^= r.s[0]
+	r.s[3] ^= r.s[1]
+	r.s[1] ^= r.s[2]
+	r.s[0] ^= r.s[3]
+	r.s[2] ^= t
+	r.s[3] = bits.RotateLeft64(r.s[3], 45)
+	return result
+}
+
+func (r *xoshiro256) nextDouble() float64 {
+	return float64(r.next()) / (float64(math.MaxUint64) + 1)
+}
+
+func (r *xoshiro256) nextInt(low, high uint64) uint64 {
+	return uint64(r.nextDouble()*float64(high-low+1)) + low
+}
+
+// randomSampler is the alias method of Vose, walking the indexes in reverse
+// like the reference implementation so that the samples match.
+type randomSampler struct {
+	probs   []float64
+	aliases []int
+}
+
+func newRandomSampler(probs []float64) *randomSampler {
+	sum := 0.0
+	for _, p := range probs {
+		sum += p
+	}
+	n := len(probs)
+	scaled := make([]float64, n)
+	for i, p := range probs {
+		scaled[i] = p * float64(n) / sum
+	}
+	var small, large []int
+	for i := n - 1; i >= 0; i-- {
+		if scaled[i] < 1 {
+			small = append(small, i)
+		} else {
+			large = append(large, i)
+		}
+	}
+	sampler := &randomSampler{probs: make([]float64, n), aliases: make([]int, n)}
+	for len(small) != 0 && len(large) != 0 {
+		a, g := small[len(small)-1], large[len(large)-1]
+		small, large = small[:len(small)-1], large[:len(large)-1]
+		sampler.probs[a] = scaled[a]
+		sampler.aliases[a] = g
+		scaled[g] += scaled[a] - 1
+		if scaled[g] < 1 {
+			small = append(small, g)
+		} else {
+			large = append(large, g)
+		}
+	}
+	for _, i := range large {
+		sampler.probs[i] = 1
+	}
+	for _, i := range small {
+		sampler.probs[i] = 1
+	}
+	return sampler
+}
+
+func (s *randomSampler) next(rng func() float64) int {
+	r1, r2 := rng(), rng()
+	i := int(float64(len(s.probs)) * r1)
+	if r2 < s.probs[i] {
+		return i
+	}
+	return s.aliases[i]
+}
+
+// FountainDecoder rebuilds a message from parts received in any order, a
+// mixed part is reduced by the known fragments until a single one is left.
+type FountainDecoder struct {
+	seqLen     int
+	messageLen int
+	checksum   uint32
+	fragments  map[int][]byte
+	mixed      map[string]*mixedPart
+	seen       map[uint32]bool
+	message    []byte
+}
+
+type mixedPart struct {
+	indexes []int
+	data    []byte
+}
+
+func NewFountainDecoder() *FountainDecoder {
+	return &FountainDecoder{}
+}
+
+// Receive adds a part, parts of another message are rejected.
+func (d *FountainDecoder) Receive(part *Part) error {
+	if d.message != nil {
+		return nil
+	}
+	if part.SeqLen <= 0 || part.MessageLen <= 0 || part.SeqNum == 0 ||
+		(part.MessageLen+part.SeqLen-1)/part.SeqLen != len(part.Data) {
+		return InvalidPartError{v: part.SeqNum}
+	}
+	if d.fragments == nil {
+		d.seqLen, d.messageLen, d.checksum = part.SeqLen, part.MessageLen, part.Checksum
+		d.fragments = make(map[int][]byte)
+		d.mixed = make(map[string]*mixedPart)
+		d.seen = make(map[uint32]bool)
+	} else if part.SeqLen != d.seqLen || part.MessageLen != d.messageLen || part.Checksum != d.checksum ||
+		len(part.Data) != len(d.anyData()) {
+		return InconsistentPartError{v: part.SeqNum}
+	}
+	if d.seen[part.SeqNum] {
+		return nil
+	}
+	d.seen[part.SeqNum] = true
+	indexes := part.Indexes()
+	slices.Sort(indexes)
+	d.process(&mixedPart{indexes: indexes, data: slices.Clone(part.Data)})
+	return d.finish()
+}
+
+// Done tells whether the message has been rebuilt.
+func (d *FountainDecoder) Done() bool {
+	return d.message != nil
+}
+
+// Message returns the rebuilt message, or nil if not done yet.
+func (d *FountainDecoder) Message() []byte {
+	return d.message
+}
+
+// Progress returns the number of fragments known out of the total.
+func (d *FountainDecoder) Progress() (int, int) {
+	return len(d.fragments), d.seqLen
+}
+
+func (d *FountainDecoder) anyData() []byte {
+	for _, fragment := range d.fragments {
+		return fragment
+	}
+	for _, part := range d.mixed {
+		return part.data
+	}
+	return nil
+}
+
+func (d *FountainDecoder) process(part *mixedPart) {
+	queue := []*mixedPart{part}
+	for len(queue) != 0 {
+		part, queue = queue[0], queue[1:]
+		part = d.reduce(part)
+		switch len(part.indexes) {
+		case 0:
+			continue
+		case 1:
+			index := part.indexes[0]
+			if _, exist := d.fragments[index]; exist {
+				continue
+			}
+			d.fragments[index] = part.data
+			// A new fragment may reduce the mixed parts waiting for it.
+			for key, mixed := range d.mixed {
+				if slices.Contains(mixed.indexes, index) {
+					delete(d.mixed, key)
+					queue = append(queue, mixed)
+				}
+			}
+		default:
+			key := mixedKey(part.indexes)
+			if _, exist := d.mixed[key]; exist {
+				continue
+			}
+			// Mixed parts reduce each other when one is a subset of the other.
+			reduced := false
+			for _, mixed := range d.mixed {
+				if isSubset(mixed.indexes, part.indexes) {
+					queue, reduced = append(queue, subtract(part, mixed)), true
+					break
+				}
+			}
+			if reduced {
+				continue
+			}
+			for other, mixed := range d.mixed {
+				if isSubset(part.indexes, mixed.indexes) {
+					delete(d.mixed, other)
+					queue = append(queue, subtract(mixed, part))
+				}
+			}
+			d.mixed[key] = part
+		}
+	}
+}
+
+// reduce removes the known fragments from a mixed part.
+func (d *FountainDecoder) reduce(part *mixedPart) *mixedPart {
+	indexes := make([]int, 0, len(part.indexes))
+	data := slices.Clone(part.data)
+	for _, index := range part.indexes {
+		if fragment, exist := d.fragments[index]; exist {
+			xorInto(data, fragment)
+		} else {
+			indexes = append(indexes, index)
+		}
+	}
+	return &mixedPart{indexes: indexes, data: data}
+}
+
+func (d *FountainDecoder) finish() error {
+	if len(d.fragments) != d.seqLen {
+		return nil
+	}
+	message := make([]byte, 0, d.seqLen*len(d.fragments[0]))
+	for index := range d.seqLen {
+		message = append(message, d.fragments[index]...)
+	}
+	message = message[:d.messageLen]
+	if checksum := crc32.ChecksumIEEE(message); checksum != d.checksum {
+		return ChecksumMismatchError{v: d.checksum, u: checksum}
+	}
+	d.message = message
+	return nil
+}
+
+// isSubset tells whether the sorted indexes a are a strict subset of b.
+func isSubset(a, b []int) bool {
+	if len(a) >= len(b) {
+		return false
+	}
+	for _, index := range a {
+		if _, found := slices.BinarySearch(b, index); !found {
+			return false
+		}
+	}
+	return true
+}
+
+// subtract removes the fragments of b from a, b being a subset of a.
+func subtract(a, b *mixedPart) *mixedPart {
+	indexes := make([]int, 0, len(a.indexes)-len(b.indexes))
+	for _, index := range a.indexes {
+		if _, found := slices.BinarySearch(b.indexes, index); !found {
+			indexes = append(indexes, index)
+		}
+	}
+	data := slices.Clone(a.data)
+	xorInto(data, b.data)
+	return &mixedPart{indexes: indexes, data: data}
+}
+
+func mixedKey(indexes []int) string {
+	key := make([]byte, 0, 4*len(indexes))
+	for _, index := range indexes {
+		key = binary.BigEndian.AppendUint32(key, uint32(index))
+	}
+	return string(key)
+}
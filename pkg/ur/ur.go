@@ -0,0 +1,263 @@
+package ur
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Uniform Resources carry CBOR in bytewords, a message too large for a single
+// QR code is split into the parts of a fountain code, with one UR per part:
+//
+//	ur:bytes/<bytewords>
+//	ur:bytes/<seq num>-<seq len>/<bytewords>
+const (
+	Scheme    = "ur"
+	TypeBytes = "bytes"
+
+	cborUint  = 0
+	cborBytes = 2
+	cborArray = 4
+
+	partFields = 5
+)
+
+type InvalidMessageError struct{ v int }
+
+func (e InvalidMessageError) Error() string {
+	return fmt.Sprintf("ur: invalid message length(%v)", e.v)
+}
+
+type InvalidFragmentLenError struct{ v int }
+
+func (e InvalidFragmentLenError) Error() string {
+	return fmt.Sprintf("ur: invalid fragment length(%v)", e.v)
+}
+
+type InvalidBytewordsError struct{ v string }
+
+func (e InvalidBytewordsError) Error() string {
+	return fmt.Sprintf("ur: invalid bytewords(%s)", e.v)
+}
+
+type ChecksumMismatchError struct{ v, u uint32 }
+
+func (e ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("ur: checksum mismatch(%08x != %08x)", e.v, e.u)
+}
+
+type InvalidPartError struct{ v uint32 }
+
+func (e InvalidPartError) Error() string {
+	return fmt.Sprintf("ur: invalid part(%v)", e.v)
+}
+
+type InconsistentPartError struct{ v uint32 }
+
+func (e InconsistentPartError) Error() string {
+	return fmt.Sprintf("ur: part(%v) of another message", e.v)
+}
+
+type InvalidURError struct{ v string }
+
+func (e InvalidURError) Error() string {
+	return fmt.Sprintf("ur: invalid ur(%s)", e.v)
+}
+
+type InvalidCBORError struct{ v int }
+
+func (e InvalidCBORError) Error() string {
+	return fmt.Sprintf("ur: invalid cbor at offset(%v)", e.v)
+}
+
+// Encoder emits the URs of a byte string, the first SeqLen ones are enough
+// when none of them is lost, the following ones let a scanner catch up.
+type Encoder struct {
+	fountain *FountainEncoder
+	single   string
+}
+
+func NewEncoder(data []byte, maxFragmentLen int) (*Encoder, error) {
+	message := appendCBORHead(nil, cborBytes, uint64(len(data)))
+	message = append(message, data...)
+	fountain, err := NewFountainEncoder(message, maxFragmentLen)
+	if err != nil {
+		return nil, err
+	}
+	encoder := &Encoder{fountain: fountain}
+	if fountain.SeqLen() == 1 {
+		encoder.single = Scheme + ":" + TypeBytes + "/" + EncodeBytewords(message)
+	}
+	return encoder, nil
+}
+
+// SeqLen returns the number of parts needed without loss.
+func (e *Encoder) SeqLen() int {
+	return e.fountain.SeqLen()
+}
+
+func (e *Encoder) NextPart() string {
+	if len(e.single) != 0 {
+		return e.single
+	}
+	part := e.fountain.NextPart()
+	return fmt.Sprintf("%s:%s/%v-%v/%s", Scheme, TypeBytes, part.SeqNum, part.SeqLen, EncodeBytewords(part.cbor()))
+}
+
+// Decoder collects URs until the byte string is rebuilt.
+type Decoder struct {
+	fountain *FountainDecoder
+	data     []byte
+}
+
+func NewDecoder() *Decoder {
+	return &Decoder{fountain: NewFountainDecoder()}
+}
+
+func (d *Decoder) Receive(ur string) error {
+	if d.data != nil {
+		return nil
+	}
+	fields := strings.Split(strings.ToLower(strings.TrimSpace(ur)), "/")
+	if len(fields) < 2 || len(fields) > 3 || fields[0] != Scheme+":"+TypeBytes {
+		return InvalidURError{v: ur}
+	}
+	payload, err := DecodeBytewords(fields[len(fields)-1])
+	if err != nil {
+		return err
+	}
+	message := payload
+	if len(fields) == 3 {
+		part, err := parsePart(payload)
+		if err != nil {
+			return err
+		}
+		if seq := strconv.FormatUint(uint64(part.SeqNum), 10) + "-" + strconv.Itoa(part.SeqLen); seq != fields[1] {
+			return InvalidURError{v: ur}
+		}
+		if err := d.fountain.Receive(part); err != nil {
+			return err
+		}
+		if message = d.fountain.Message(); message == nil {
+			return nil
+		}
+	}
+	major, length, offset, err := readCBORHead(message, 0)
+	if err != nil {
+		return err
+	}
+	if major != cborBytes || uint64(len(message)-offset) != length {
+		return InvalidCBORError{v: 0}
+	}
+	d.data = message[offset:]
+	return nil
+}
+
+// Done tells whether the byte string has been rebuilt.
+func (d *Decoder) Done() bool {
+	return d.data != nil
+}
+
+// Data returns the byte string, or nil if not done yet.
+func (d *Decoder) Data() []byte {
+	return d.data
+}
+
+// Progress returns the number of fragments known out of the total.
+func (d *Decoder) Progress() (int, int) {
+	return d.fountain.Progress()
+}
+
+func (p *Part) cbor() []byte {
+	data := appendCBORHead(nil, cborArray, partFields)
+	data = appendCBORHead(data, cborUint, uint64(p.SeqNum))
+	data = appendCBORHead(data, cborUint, uint64(p.SeqLen))
+	data = appendCBORHead(data, cborUint, uint64(p.MessageLen))
+	data = appendCBORHead(data, cborUint, uint64(p.Checksum))
+	data = appendCBORHead(data, cborBytes, uint64(len(p.Data)))
+	return append(data, p.Data...)
+}
+
+func parsePart(data []byte) (*Part, error) {
+	major, length, offset, err := readCBORHead(data, 0)
+	if err != nil {
+		return nil, err
+	}
+	if major != cborArray || length != partFields {
+		return nil, InvalidCBORError{v: 0}
+	}
+	var values [partFields - 1]uint64
+	for i := range values {
+		start := offset
+		if major, values[i], offset, err = readCBORHead(data, offset); err != nil {
+			return nil, err
+		}
+		if major != cborUint || values[i] > 1<<32-1 {
+			return nil, InvalidCBORError{v: start}
+		}
+	}
+	start := offset
+	if major, length, offset, err = readCBORHead(data, offset); err != nil {
+		return nil, err
+	}
+	if major != cborBytes || uint64(len(data)-offset) != length {
+		return nil, InvalidCBORError{v: start}
+	}
+	return &Part{
+		SeqNum:     uint32(values[0]),
+		SeqLen:     int(values[1]),
+		MessageLen: int(values[2]),
+		Checksum:   uint32(values[3]),
+		Data:       data[offset:],
+	}, nil
+}
+
+// appendCBORHead appends the head of a CBOR item in its shortest form.
+func appendCBORHead(data []byte, major byte, value uint64) []byte {
+	major <<= 5
+	switch {
+	case value < 24:
+		return append(data, major|byte(value))
+	case value <= 0xff:
+		return append(data, major|24, byte(value))
+	case value <= 0xffff:
+		return binary.BigEndian.AppendUint16(append(data, major|25), uint16(value))
+	case value <= 0xffffffff:
+		return binary.BigEndian.AppendUint32(append(data, major|26), uint32(value))
+	default:
+		return binary.BigEndian.AppendUint64(append(data, major|27), value)
+	}
+}
+
+func readCBORHead(data []byte, offset int) (byte, uint64, int, error) {
+	if offset >= len(data) {
+		return 0, 0, 0, InvalidCBORError{v: offset}
+	}
+	major, info := data[offset]>>5, data[offset]&0x1f
+	offset++
+	if info < 24 {
+		return major, uint64(info), offset, nil
+	}
+	size := 0
+	switch info {
+	case 24:
+		size = 1
+	case 25:
+		size = 2
+	case 26:
+		size = 4
+	case 27:
+		size = 8
+	default:
+		return 0, 0, 0, InvalidCBORError{v: offset - 1}
+	}
+	if len(data)-offset < size {
+		return 0, 0, 0, InvalidCBORError{v: offset}
+	}
+	value := uint64(0)
+	for _, b := range data[offset : offset+size] {
+		value = value<<8 | uint64(b)
+	}
+	return major, value, offset + size, nil
+}
@@ -0,0 +1,118 @@
+package ur
+
+import (
+	"bytes"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestBytewords(t *testing.T) {
+	data := []byte{0, 1, 2, 128, 255}
+	encoded := EncodeBytewords(data)
+	if want := "aeadaolazmjendeoti"; encoded != want {
+		t.Fatalf("got = %v, want = %v", encoded, want)
+	}
+	decoded, err := DecodeBytewords(strings.ToUpper(encoded))
+	if err != nil {
+		t.Fatalf("failed to decode bytewords: %v", err)
+	}
+	if !bytes.Equal(decoded, data) {
+		t.Fatalf("got = %v, want = %v", decoded, data)
+	}
+	for _, invalid := range []string{"aeadaolazmjendeotx", "aeadaolazmjendeot", "aeadaolazmjendeoto", "ae"} {
+		if _, err := DecodeBytewords(invalid); err == nil {
+			t.Errorf("expect error with %q", invalid)
+		}
+	}
+}
+
+func TestXoshiro256(t *testing.T) {
+	rng := newXoshiro256([]byte("Wolf"))
+	want := []uint64{42, 81, 85, 8, 82, 84, 76, 73, 70, 88}
+	for i := range want {
+		if got := rng.next() % 100; got != want[i] {
+			t.Fatalf("number %v got = %v, want = %v", i, got, want[i])
+		}
+	}
+}
+
+func TestShuffled(t *testing.T) {
+	rng := newXoshiro256([]byte("Wolf"))
+	items := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	want := [][]int{
+		{6, 4, 9, 3, 10, 5, 7, 8, 1, 2},
+		{10, 8, 6, 5, 1, 2, 3, 9, 7, 4},
+	}
+	for i := range want {
+		if got := shuffled(items, rng); !slices.Equal(got, want[i]) {
+			t.Fatalf("shuffle %v got = %v, want = %v", i, got, want[i])
+		}
+	}
+}
+
+func TestEncoderDecoder(t *testing.T) {
+	rng := newXoshiro256([]byte("Wolf"))
+	data := make([]byte, 1000)
+	for i := range data {
+		data[i] = byte(rng.nextInt(0, 255))
+	}
+	encoder, err := NewEncoder(data, 100)
+	if err != nil {
+		t.Fatalf("failed to create encoder: %v", err)
+	}
+	if encoder.SeqLen() != 11 {
+		t.Fatalf("got = %v, want = %v", encoder.SeqLen(), 11)
+	}
+	decoder := NewDecoder()
+	received := 0
+	for seqNum := 1; !decoder.Done(); seqNum++ {
+		part := encoder.NextPart()
+		if !strings.HasPrefix(part, "ur:bytes/"+strconv.Itoa(seqNum)+"-11/") {
+			t.Fatalf("got = %v", part)
+		}
+		if seqNum > 1000 {
+			t.Fatalf("failed to decode after %v parts", received)
+		}
+		// Lose every third part to exercise the mixed parts.
+		if seqNum%3 == 0 {
+			continue
+		}
+		if err := decoder.Receive(strings.ToUpper(part)); err != nil {
+			t.Fatalf("failed to receive part %v: %v", seqNum, err)
+		}
+		received++
+	}
+	if !bytes.Equal(decoder.Data(), data) {
+		t.Fatalf("got = %v, want = %v", decoder.Data(), data)
+	}
+
+	encoder, err = NewEncoder([]byte("To be, or not to be"), 100)
+	if err != nil {
+		t.Fatalf("failed to create encoder: %v", err)
+	}
+	single := encoder.NextPart()
+	if strings.Count(single, "/") != 1 || encoder.NextPart() != single {
+		t.Fatalf("got = %v", single)
+	}
+	decoder = NewDecoder()
+	if err := decoder.Receive(single); err != nil || string(decoder.Data()) != "To be, or not to be" {
+		t.Fatalf("got = %q, %v", decoder.Data(), err)
+	}
+
+	other, _ := NewEncoder(bytes.Repeat([]byte{1}, 1000), 100)
+	decoder = NewDecoder()
+	first, _ := NewEncoder(data, 100)
+	if err := decoder.Receive(first.NextPart()); err != nil {
+		t.Fatalf("failed to receive part: %v", err)
+	}
+	if err := decoder.Receive(other.NextPart()); err == nil {
+		t.Fatalf("expect error with part of another message")
+	}
+	for _, invalid := range []string{"ur:bytes", "ur:seed/aeadaolazmjendeoti", "ur:bytes/2-11/aeadaolazmjendeoti"} {
+		if err := NewDecoder().Receive(invalid); err == nil {
+			t.Errorf("expect error with %q", invalid)
+		}
+	}
+}
@@ -5,10 +5,9 @@ package shamir
 
 import (
 	"crypto/rand"
-	"crypto/subtle"
+	"encoding/binary"
 	"fmt"
-	mathrand "math/rand"
-	"time"
+	"io"
 )
 
 const (
@@ -16,113 +15,120 @@ const (
 	// when using Split on a secret. This is caused by appending
 	// a one byte tag to the share.
 	ShareOverhead = 1
-)
 
-// polynomial represents a polynomial of arbitrary degree
-type polynomial struct {
-	coefficients []uint8
-}
+	// splitChunkSize is the number of secret bytes whose polynomials
+	// are generated at once, which bounds the memory used by Split.
+	splitChunkSize = 4096
 
-// makePolynomial constructs a random polynomial of the given
-// degree but with the provided intercept value.
-func makePolynomial(intercept, degree uint8) (polynomial, error) {
-	// Create a wrapper
-	p := polynomial{
-		coefficients: make([]byte, degree+1),
-	}
+	// lowBits and highBits select the low seven bits and the lowest bit of
+	// each of the eight bytes packed in a uint64.
+	lowBits  = 0x7f7f7f7f7f7f7f7f
+	highBits = 0x0101010101010101
+)
 
-	// Ensure the intercept is set
-	p.coefficients[0] = intercept
+// inverse calculates the inverse of a number in GF(2^8)
+func inverse(a uint8) uint8 {
+	return uint8(inverseLanes(uint64(a)))
+}
 
-	// Assign random co-efficients to the polynomial
-	if _, err := rand.Read(p.coefficients[1:]); err != nil {
-		return p, err
-	}
+// mult multiplies two numbers in GF(2^8)
+func mult(a, b uint8) (out uint8) {
+	return uint8(mulLanes(uint64(a), uint64(b)))
+}
 
-	return p, nil
+// multLanes multiplies each of the eight bytes packed in a by b in
+// GF(2^8), handling eight bytes of the secret at the cost of one.
+func multLanes(a uint64, b uint8) uint64 {
+	return mulLanes(a, uint64(b)*highBits)
 }
 
-// evaluate returns the value of the polynomial for the given x
-func (p *polynomial) evaluate(x uint8) uint8 {
-	// Special case the origin
-	if x == 0 {
-		return p.coefficients[0]
+// mulLanes multiplies each of the eight bytes packed in a by the byte in
+// the same lane of b in GF(2^8). It is a carry-less shift and add without
+// any data dependent branch or table lookup, so it runs in constant time.
+// All the arithmetic of the field goes through it.
+func mulLanes(a, b uint64) uint64 {
+	var r uint64
+	for i := 7; i >= 0; i-- {
+		r = ((r & lowBits) << 1) ^ (((r >> 7) & highBits) * 0x1B)
+		r ^= a & (((b >> i) & highBits) * 0xFF)
 	}
+	return r
+}
 
-	// Compute the polynomial value using Horner's method.
-	degree := len(p.coefficients) - 1
-	out := p.coefficients[degree]
-	for i := degree - 1; i >= 0; i-- {
-		coeff := p.coefficients[i]
-		out = add(mult(out, x), coeff)
+// inverseLanes inverts each of the eight bytes packed in a in GF(2^8), as
+// a^254 by square and multiply, and maps zero to zero.
+func inverseLanes(a uint64) uint64 {
+	r := uint64(highBits)
+	for i := 7; i >= 0; i-- {
+		r = mulLanes(r, r)
+		if (254>>i)&1 == 1 {
+			r = mulLanes(r, a)
+		}
 	}
-	return out
+	return r
 }
 
-// interpolatePolynomial takes N sample points and returns
-// the value at a given x using a lagrange interpolation.
-func interpolatePolynomial(x_samples, y_samples []uint8, x uint8) uint8 {
-	limit := len(x_samples)
-	var result, basis uint8
-	for i := 0; i < limit; i++ {
-		basis = 1
-		for j := 0; j < limit; j++ {
+// lagrangeBasis returns the lagrange basis of the given x samples
+// evaluated at x, so that the value of the interpolated polynomial at x
+// is the sum of each y sample multiplied by its basis. The basis only
+// depends on the x coordinates, which are public, so it is computed once
+// per call rather than once per byte of the secret. The numerators and
+// denominators are multiplied out first, then the denominators are
+// inverted eight at a time.
+func lagrangeBasis(x_samples []uint8, x uint8) []uint8 {
+	nums := make([]uint8, len(x_samples))
+	denoms := make([]uint8, len(x_samples))
+	for i := range x_samples {
+		nums[i], denoms[i] = 1, 1
+		for j := range x_samples {
 			if i == j {
 				continue
 			}
-			num := add(x, x_samples[j])
-			denom := add(x_samples[i], x_samples[j])
-			term := div(num, denom)
-			basis = mult(basis, term)
+			nums[i] = mult(nums[i], add(x, x_samples[j]))
+			denoms[i] = mult(denoms[i], add(x_samples[i], x_samples[j]))
 		}
-		group := mult(y_samples[i], basis)
-		result = add(result, group)
 	}
-	return result
+	basis := make([]uint8, len(x_samples))
+	for idx := 0; idx < len(basis); idx += 8 {
+		lanes := mulLanes(loadLanes(nums[idx:]), inverseLanes(loadLanes(denoms[idx:])))
+		storeLanes(basis[idx:min(idx+8, len(basis))], lanes)
+	}
+	return basis
 }
 
-// div divides two numbers in GF(2^8)
-func div(a, b uint8) uint8 {
-	if b == 0 {
-		// leaks some timing information but we don't care anyways as this
-		// should never happen, hence the panic
-		panic("divide by zero")
+// interpolateBytes computes the value at the basis point for each byte
+// position of the parts, ignoring the trailing x tag, eight bytes at a time.
+func interpolateBytes(parts [][]byte, basis []uint8, out []byte) {
+	for idx := 0; idx < len(out); idx += 8 {
+		var lanes uint64
+		for i, part := range parts {
+			lanes ^= multLanes(loadLanes(part[idx:len(out)]), basis[i])
+		}
+		storeLanes(out[idx:], lanes)
 	}
-
-	ret := int(mult(a, inverse(b)))
-
-	// Ensure we return zero if a is zero but aren't subject to timing attacks
-	ret = subtle.ConstantTimeSelect(subtle.ConstantTimeByteEq(a, 0), 0, ret)
-	return uint8(ret)
 }
 
-// inverse calculates the inverse of a number in GF(2^8)
-func inverse(a uint8) uint8 {
-	b := mult(a, a)
-	c := mult(a, b)
-	b = mult(c, c)
-	b = mult(b, b)
-	c = mult(b, c)
-	b = mult(b, b)
-	b = mult(b, b)
-	b = mult(b, c)
-	b = mult(b, b)
-	b = mult(a, b)
-
-	return mult(b, b)
+// loadLanes packs up to eight bytes in little endian order.
+func loadLanes(b []byte) uint64 {
+	if len(b) >= 8 {
+		return binary.LittleEndian.Uint64(b)
+	}
+	var v uint64
+	for i := len(b) - 1; i >= 0; i-- {
+		v = v<<8 | uint64(b[i])
+	}
+	return v
 }
 
-// mult multiplies two numbers in GF(2^8)
-func mult(a, b uint8) (out uint8) {
-	var r uint8 = 0
-	var i uint8 = 8
-
-	for i > 0 {
-		i--
-		r = (-(b >> i & 1) & a) ^ (-(r >> 7) & 0x1B) ^ (r + r)
+// storeLanes unpacks up to eight bytes in little endian order.
+func storeLanes(b []byte, v uint64) {
+	if len(b) >= 8 {
+		binary.LittleEndian.PutUint64(b, v)
+		return
+	}
+	for i := range b {
+		b[i] = uint8(v >> (8 * i))
 	}
-
-	return r
 }
 
 // add combines two numbers in GF(2^8)
@@ -137,6 +143,14 @@ func add(a, b uint8) uint8 {
 // than 256. The returned shares are each one byte longer than the secret
 // as they attach a tag used to reconstruct the secret.
 func Split(secret []byte, parts, threshold int) ([][]byte, error) {
+	return SplitWithReader(rand.Reader, secret, parts, threshold)
+}
+
+// SplitWithReader is like Split, but all the randomness, both the
+// x coordinates and the co-efficients of the polynomials, is read from
+// the given reader. It should be a cryptographically secure source
+// unless the output is meant to be reproduced, e.g. in tests.
+func SplitWithReader(random io.Reader, secret []byte, parts, threshold int) ([][]byte, error) {
 	// Sanity check the input
 	if parts < threshold {
 		return nil, fmt.Errorf("parts cannot be less than threshold")
@@ -155,8 +169,10 @@ func Split(secret []byte, parts, threshold int) ([][]byte, error) {
 	}
 
 	// Generate random list of x coordinates
-	mathrand.Seed(time.Now().UnixNano())
-	xCoordinates := mathrand.Perm(255)
+	xCoordinates, err := randomPerm(random, 255, parts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate x coordinates: %w", err)
+	}
 
 	// Allocate the output array, initialize the final byte
 	// of the output with the offset. The representation of each
@@ -170,20 +186,43 @@ func Split(secret []byte, parts, threshold int) ([][]byte, error) {
 	// Construct a random polynomial for each byte of the secret.
 	// Because we are using a field of size 256, we can only represent
 	// a single byte as the intercept of the polynomial, so we must
-	// use a new polynomial for each byte.
-	for idx, val := range secret {
-		p, err := makePolynomial(val, uint8(threshold-1))
-		if err != nil {
+	// use a new polynomial for each byte. The co-efficients are read
+	// chunk by chunk in the same order as one polynomial after another,
+	// and eight polynomials are evaluated at once.
+	degree := threshold - 1
+	chunk := make([]byte, splitChunkSize*degree)
+	lanes := make([]uint64, threshold)
+	for start := 0; start < len(secret); start += splitChunkSize {
+		end := min(start+splitChunkSize, len(secret))
+		coefficients := chunk[:(end-start)*degree]
+		if _, err := io.ReadFull(random, coefficients); err != nil {
 			return nil, fmt.Errorf("failed to generate polynomial: %w", err)
 		}
 
-		// Generate a `parts` number of (x,y) pairs
-		// We cheat by encoding the x value once as the final index,
-		// so that it only needs to be stored once.
-		for i := 0; i < parts; i++ {
-			x := uint8(xCoordinates[i]) + 1
-			y := p.evaluate(x)
-			out[i][idx] = y
+		for idx := start; idx < end; idx += 8 {
+			// Gather the co-efficients of the same degree together,
+			// lane l holds the polynomial of the byte idx+l
+			width := min(8, end-idx)
+			lanes[0] = loadLanes(secret[idx : idx+width])
+			for d := 1; d < threshold; d++ {
+				var v uint64
+				for l := width - 1; l >= 0; l-- {
+					v = v<<8 | uint64(coefficients[(idx-start+l)*degree+d-1])
+				}
+				lanes[d] = v
+			}
+
+			// Generate a `parts` number of (x,y) pairs
+			// We cheat by encoding the x value once as the final index,
+			// so that it only needs to be stored once.
+			for i := 0; i < parts; i++ {
+				x := uint8(xCoordinates[i]) + 1
+				y := lanes[degree]
+				for d := degree - 1; d >= 0; d-- {
+					y = multLanes(y, x) ^ lanes[d]
+				}
+				storeLanes(out[i][idx:idx+width], y)
+			}
 		}
 	}
 
@@ -191,6 +230,34 @@ func Split(secret []byte, parts, threshold int) ([][]byte, error) {
 	return out, nil
 }
 
+// randomPerm returns the first k elements of a uniformly random
+// permutation of [0, n), using a partial Fisher-Yates shuffle.
+// The n must not exceed 256 as one byte is read per draw.
+func randomPerm(random io.Reader, n, k int) ([]int, error) {
+	perm := make([]int, n)
+	for i := range perm {
+		perm[i] = i
+	}
+	var buf [1]byte
+	for i := 0; i < k; i++ {
+		// Reject the bytes beyond the largest multiple of the
+		// bound to avoid modulo bias
+		bound := n - i
+		limit := 256 - 256%bound
+		for {
+			if _, err := io.ReadFull(random, buf[:]); err != nil {
+				return nil, err
+			}
+			if int(buf[0]) < limit {
+				break
+			}
+		}
+		j := i + int(buf[0])%bound
+		perm[i], perm[j] = perm[j], perm[i]
+	}
+	return perm[:k], nil
+}
+
 // Combine is used to reverse a Split and reconstruct a secret
 // once a `threshold` number of parts are available.
 func Combine(parts [][]byte) ([]byte, error) {
@@ -215,10 +282,56 @@ func Combine(parts [][]byte) ([]byte, error) {
 
 	// Buffer to store the samples
 	x_samples := make([]uint8, len(parts))
-	y_samples := make([]uint8, len(parts))
 
 	// Set the x value for each sample and ensure no x_sample values are the same,
-	// otherwise div() can be unhappy
+	// otherwise the lagrange basis would divide by zero
+	checkMap := map[byte]bool{}
+	for i, part := range parts {
+		samp := part[firstPartLen-1]
+		if exists := checkMap[samp]; exists {
+			return nil, fmt.Errorf("duplicate part detected")
+		}
+		checkMap[samp] = true
+		x_samples[i] = samp
+	}
+
+	// Reconstruct each byte by interpolating the polynomial
+	// and computing the value at 0 to get the intercept
+	interpolateBytes(parts, lagrangeBasis(x_samples, 0), secret)
+	return secret, nil
+}
+
+// Evaluate is used to issue an additional share of a secret that has
+// already been split. The polynomials are reconstructed from at least a
+// `threshold` number of parts and evaluated at the given x coordinate,
+// which must be non-zero and must not collide with any existing share.
+func Evaluate(parts [][]byte, x uint8) ([]byte, error) {
+	// Verify enough parts provided
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("less than two parts cannot be used to evaluate the polynomial")
+	}
+
+	// The origin is the secret itself, never hand it out as a share
+	if x == 0 {
+		return nil, fmt.Errorf("x coordinate cannot be zero")
+	}
+
+	// Verify the parts are all the same length
+	firstPartLen := len(parts[0])
+	if firstPartLen < 2 {
+		return nil, fmt.Errorf("parts must be at least two bytes")
+	}
+	for i := 1; i < len(parts); i++ {
+		if len(parts[i]) != firstPartLen {
+			return nil, fmt.Errorf("all parts must be the same length")
+		}
+	}
+
+	// Buffer to store the samples
+	x_samples := make([]uint8, len(parts))
+
+	// Set the x value for each sample and ensure no x_sample values are the same,
+	// and that the requested x coordinate is not already in use
 	checkMap := map[byte]bool{}
 	for i, part := range parts {
 		samp := part[firstPartLen-1]
@@ -228,19 +341,200 @@ func Combine(parts [][]byte) ([]byte, error) {
 		checkMap[samp] = true
 		x_samples[i] = samp
 	}
+	if checkMap[x] {
+		return nil, fmt.Errorf("x coordinate already in use")
+	}
+
+	// Allocate the output, the final byte is the x coordinate tag
+	out := make([]byte, firstPartLen)
+	out[firstPartLen-1] = x
+
+	// Interpolate the polynomial and compute the value at x for each byte
+	interpolateBytes(parts, lagrangeBasis(x_samples, x), out[:firstPartLen-1])
+	return out, nil
+}
+
+// CombineRobust is used to reconstruct a secret when more than `threshold`
+// parts are available and some of them may be corrupted. The polynomial of
+// each byte is decoded with the Berlekamp-Welch algorithm, which corrects up
+// to (len(parts)-threshold)/2 corrupted parts in polynomial time. It returns
+// the secret along with the positions of the parts that disagree with it.
+// The result is only accepted when it is unambiguous, i.e. no other
+// polynomial of the same degree could be agreed by as many parts.
+func CombineRobust(parts [][]byte, threshold int) ([]byte, []int, error) {
+	// Sanity check the input
+	if threshold < 2 {
+		return nil, nil, fmt.Errorf("threshold must be at least 2")
+	}
+	if len(parts) < threshold {
+		return nil, nil, fmt.Errorf("less than threshold parts cannot be used to reconstruct the secret")
+	}
+
+	// Verify the parts are all the same length
+	firstPartLen := len(parts[0])
+	if firstPartLen < 2 {
+		return nil, nil, fmt.Errorf("parts must be at least two bytes")
+	}
+	for i := 1; i < len(parts); i++ {
+		if len(parts[i]) != firstPartLen {
+			return nil, nil, fmt.Errorf("all parts must be the same length")
+		}
+	}
+
+	// Ensure no x_sample values are the same
+	x_samples := make([]uint8, len(parts))
+	checkMap := map[byte]bool{}
+	for i, part := range parts {
+		samp := part[firstPartLen-1]
+		if exists := checkMap[samp]; exists {
+			return nil, nil, fmt.Errorf("duplicate part detected")
+		}
+		checkMap[samp] = true
+		x_samples[i] = samp
+	}
 
-	// Reconstruct each byte
+	// Decode the polynomial of each byte on its own, a part is bad as soon
+	// as one of its bytes disagrees
+	secret := make([]byte, firstPartLen-1)
+	y_samples := make([]uint8, len(parts))
+	disagreed := make([]bool, len(parts))
 	for idx := range secret {
-		// Set the y value for each sample
 		for i, part := range parts {
 			y_samples[i] = part[idx]
 		}
+		p, ok := berlekampWelch(x_samples, y_samples, threshold)
+		if !ok {
+			return nil, nil, fmt.Errorf("not enough consistent parts to reconstruct the secret")
+		}
+		secret[idx] = p[0]
+		for i := range parts {
+			if evaluate(p, x_samples[i]) != y_samples[i] {
+				disagreed[i] = true
+			}
+		}
+	}
+	var bad []int
+	for j, ok := range disagreed {
+		if ok {
+			bad = append(bad, j)
+		}
+	}
+	return secret, bad, nil
+}
 
-		// Interpolate the polynomial and compute the value at 0
-		val := interpolatePolynomial(x_samples, y_samples, 0)
+// berlekampWelch returns the coefficients of the polynomial of degree below
+// k that passes through all but at most e = (n-k)/2 of the n samples. With
+// an error locator E of degree e, monic, and Q = P*E of degree below e+k,
+// every sample satisfies Q(x) = y*E(x), which is a linear system in the
+// coefficients of Q and E. Any solution gives the same P = Q/E.
+func berlekampWelch(x_samples, y_samples []uint8, k int) ([]uint8, bool) {
+	n := len(x_samples)
+	e := (n - k) / 2
+	unknowns := 2*e + k
+
+	// Each row holds the powers of x for Q, y times the powers of x for
+	// the non leading coefficients of E, then y*x^e on the right hand side
+	rows := make([][]uint8, n)
+	for i := range rows {
+		row := make([]uint8, unknowns+1)
+		x, y := x_samples[i], y_samples[i]
+		power := uint8(1)
+		for j := 0; j < e+k; j++ {
+			row[j] = power
+			if j < e {
+				row[e+k+j] = mult(y, power)
+			}
+			if j == e {
+				row[unknowns] = mult(y, power)
+			}
+			power = mult(power, x)
+		}
+		rows[i] = row
+	}
+	solution, ok := solveLinear(rows, unknowns)
+	if !ok {
+		return nil, false
+	}
 
-		// Evaluate the 0th value to get the intercept
-		secret[idx] = val
+	// Divide Q by E, the remainder must vanish
+	q := solution[:e+k]
+	locator := append(append([]uint8(nil), solution[e+k:]...), 1)
+	p := make([]uint8, k)
+	for d := e + k - 1; d >= e; d-- {
+		coeff := q[d]
+		p[d-e] = coeff
+		for j := 0; j <= e; j++ {
+			q[d-e+j] = add(q[d-e+j], mult(coeff, locator[j]))
+		}
 	}
-	return secret, nil
+	for _, coeff := range q[:e] {
+		if coeff != 0 {
+			return nil, false
+		}
+	}
+
+	// The polynomial must be agreed by all but at most e samples
+	agreed := 0
+	for i := range x_samples {
+		if evaluate(p, x_samples[i]) == y_samples[i] {
+			agreed++
+		}
+	}
+	return p, agreed >= n-e
+}
+
+// solveLinear solves the system of the rows, whose last column is the right
+// hand side, by Gaussian elimination. The free unknowns of an underdetermined
+// system are set to zero, and false is returned for an inconsistent one.
+func solveLinear(rows [][]uint8, unknowns int) ([]uint8, bool) {
+	pivots := make([]int, 0, unknowns)
+	r := 0
+	for c := 0; c < unknowns && r < len(rows); c++ {
+		pivot := -1
+		for i := r; i < len(rows); i++ {
+			if rows[i][c] != 0 {
+				pivot = i
+				break
+			}
+		}
+		if pivot < 0 {
+			continue
+		}
+		rows[r], rows[pivot] = rows[pivot], rows[r]
+		scale := inverse(rows[r][c])
+		for j := c; j <= unknowns; j++ {
+			rows[r][j] = mult(rows[r][j], scale)
+		}
+		for i := range rows {
+			if i == r || rows[i][c] == 0 {
+				continue
+			}
+			factor := rows[i][c]
+			for j := c; j <= unknowns; j++ {
+				rows[i][j] = add(rows[i][j], mult(factor, rows[r][j]))
+			}
+		}
+		pivots = append(pivots, c)
+		r++
+	}
+	for i := r; i < len(rows); i++ {
+		if rows[i][unknowns] != 0 {
+			return nil, false
+		}
+	}
+	solution := make([]uint8, unknowns)
+	for i, c := range pivots {
+		solution[c] = rows[i][unknowns]
+	}
+	return solution, true
+}
+
+// evaluate returns the value at x of the polynomial with the coefficients,
+// using Horner's method.
+func evaluate(coefficients []uint8, x uint8) uint8 {
+	var out uint8
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		out = add(mult(out, x), coefficients[i])
+	}
+	return out
 }
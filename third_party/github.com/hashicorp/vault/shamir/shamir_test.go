@@ -5,6 +5,8 @@ package shamir
 
 import (
 	"bytes"
+	"crypto/rand"
+	"slices"
 	"testing"
 )
 
@@ -142,60 +144,312 @@ func TestField_Mult(t *testing.T) {
 	}
 }
 
-func TestField_Divide(t *testing.T) {
-	if out := div(0, 7); out != 0 {
+func TestField_Inverse(t *testing.T) {
+	if out := inverse(0); out != 0 {
 		t.Fatalf("Bad: %v 0", out)
 	}
 
-	if out := div(3, 3); out != 1 {
-		t.Fatalf("Bad: %v 1", out)
+	for a := 1; a < 256; a++ {
+		if out := mult(uint8(a), inverse(uint8(a))); out != 1 {
+			t.Fatalf("Bad: %v * inverse(%v) = %v", a, a, out)
+		}
+	}
+}
+
+func TestEvaluate_invalid(t *testing.T) {
+	// Not enough parts
+	if _, err := Evaluate(nil, 1); err == nil {
+		t.Fatalf("should err")
+	}
+
+	out, err := Split([]byte("test"), 5, 3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Zero is the secret
+	if _, err := Evaluate(out[:3], 0); err == nil {
+		t.Fatalf("should err")
+	}
+
+	// Already in use
+	if _, err := Evaluate(out[:3], out[2][len(out[2])-1]); err == nil {
+		t.Fatalf("should err")
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	secret := []byte("test")
+
+	out, err := Split(secret, 5, 3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	used := map[byte]bool{}
+	for _, share := range out {
+		used[share[len(share)-1]] = true
+	}
+	var x uint8
+	for x = 1; used[x]; x++ {
+	}
+
+	extra, err := Evaluate(out[:3], x)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if extra[len(extra)-1] != x {
+		t.Fatalf("bad: %v", extra)
+	}
+
+	// Any existing share must agree with the polynomial
+	for i := 0; i < 5; i++ {
+		recomputed, err := Evaluate([][]byte{out[(i+1)%5], out[(i+2)%5], extra}, out[i][len(out[i])-1])
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if !bytes.Equal(recomputed, out[i]) {
+			t.Fatalf("bad: %v %v", recomputed, out[i])
+		}
 	}
 
-	if out := div(6, 3); out != 2 {
-		t.Fatalf("Bad: %v 2", out)
+	recomb, err := Combine([][]byte{out[0], out[3], extra})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recomb, secret) {
+		t.Fatalf("bad: %v %v", recomb, secret)
 	}
 }
 
-func TestPolynomial_Random(t *testing.T) {
-	p, err := makePolynomial(42, 2)
+func TestCombineRobust_invalid(t *testing.T) {
+	out, err := Split([]byte("test"), 5, 3)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	if p.coefficients[0] != 42 {
-		t.Fatalf("bad: %v", p.coefficients)
+	// Not enough parts
+	if _, _, err := CombineRobust(out[:2], 3); err == nil {
+		t.Fatalf("should err")
+	}
+
+	// Threshold too small
+	if _, _, err := CombineRobust(out, 1); err == nil {
+		t.Fatalf("should err")
+	}
+
+	// Too many corrupted parts to decide
+	parts := make([][]byte, 4)
+	for i := range parts {
+		parts[i] = append([]byte(nil), out[i]...)
+	}
+	parts[0][0] ^= 1
+	if _, _, err := CombineRobust(parts, 3); err == nil {
+		t.Fatalf("should err")
 	}
 }
 
-func TestPolynomial_Eval(t *testing.T) {
-	p, err := makePolynomial(42, 1)
+func TestCombineRobust(t *testing.T) {
+	secret := []byte("test")
+
+	out, err := Split(secret, 9, 4)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	if out := p.evaluate(0); out != 42 {
-		t.Fatalf("bad: %v", out)
+	// No corruption at all
+	recomb, bad, err := CombineRobust(out, 4)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recomb, secret) || len(bad) != 0 {
+		t.Fatalf("bad: %v %v", recomb, bad)
 	}
 
-	out := p.evaluate(1)
-	exp := add(42, mult(1, p.coefficients[1]))
-	if out != exp {
-		t.Fatalf("bad: %v %v %v", out, exp, p.coefficients)
+	// Corrupt every pair of parts in turn
+	for i := 0; i < 9; i++ {
+		for j := 0; j < i; j++ {
+			parts := make([][]byte, 9)
+			for k := range parts {
+				parts[k] = append([]byte(nil), out[k]...)
+			}
+			parts[i][1] ^= 0x5a
+			parts[j][3] ^= 0xa5
+			recomb, bad, err := CombineRobust(parts, 4)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			if !bytes.Equal(recomb, secret) {
+				t.Fatalf("bad: %v %v", recomb, secret)
+			}
+			if len(bad) != 2 || bad[0] != j || bad[1] != i {
+				t.Fatalf("bad: %v (i:%d, j:%d)", bad, i, j)
+			}
+		}
 	}
 }
 
-func TestInterpolate_Rand(t *testing.T) {
-	for i := 0; i < 256; i++ {
-		p, err := makePolynomial(uint8(i), 2)
+func TestCombineRobust_large(t *testing.T) {
+	secret := []byte("To be, or not to be, that is the question.")
+
+	out, err := Split(secret, 26, 13)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Up to (26-13)/2 corrupted parts are corrected, without trying every
+	// subset of 13 parts
+	corrupted := []int{2, 5, 11, 17, 19, 25}
+	for _, c := range corrupted {
+		out[c][c] ^= 0x5a
+		out[c][len(secret)-1] ^= 0xa5
+	}
+	recomb, bad, err := CombineRobust(out, 13)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recomb, secret) {
+		t.Fatalf("bad: %v %v", recomb, secret)
+	}
+	if !slices.Equal(bad, corrupted) {
+		t.Fatalf("bad: %v %v", bad, corrupted)
+	}
+}
+
+func TestSplitWithReader(t *testing.T) {
+	secret := []byte("test")
+	random := bytes.Repeat([]byte{7, 200, 42, 255, 0, 13}, 16)
+
+	out1, err := SplitWithReader(bytes.NewReader(random), secret, 5, 3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out2, err := SplitWithReader(bytes.NewReader(random), secret, 5, 3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for i := range out1 {
+		if !bytes.Equal(out1[i], out2[i]) {
+			t.Fatalf("bad: %v %v", out1, out2)
+		}
+	}
+
+	recomb, err := Combine(out1[1:4])
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recomb, secret) {
+		t.Fatalf("bad: %v %v", recomb, secret)
+	}
+
+	// Running out of randomness must not go unnoticed
+	if _, err := SplitWithReader(bytes.NewReader(random[:4]), secret, 5, 3); err == nil {
+		t.Fatalf("should err")
+	}
+}
+
+func TestRandomPerm(t *testing.T) {
+	random := make([]byte, 1024)
+	for i := range random {
+		random[i] = uint8(i * 37)
+	}
+	perm, err := randomPerm(bytes.NewReader(random), 255, 255)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	seen := map[int]bool{}
+	for _, v := range perm {
+		if v < 0 || v >= 255 || seen[v] {
+			t.Fatalf("bad: %v", perm)
+		}
+		seen[v] = true
+	}
+}
+
+func TestField_MultLanes(t *testing.T) {
+	// Reference bit by bit multiplication
+	reference := func(a, b uint8) uint8 {
+		var r uint8
+		for i := 7; i >= 0; i-- {
+			r = (-(b >> i & 1) & a) ^ (-(r >> 7) & 0x1B) ^ (r + r)
+		}
+		return r
+	}
+
+	for a := 0; a < 256; a++ {
+		for b := 0; b < 256; b++ {
+			lanes := multLanes(uint64(a)*0x0101010101010101, uint8(b))
+			for l := 0; l < 8; l++ {
+				if out := uint8(lanes >> (8 * l)); out != reference(uint8(a), uint8(b)) {
+					t.Fatalf("Bad: %v * %v lane %d", a, b, l)
+				}
+			}
+		}
+	}
+}
+
+func TestCombine_Lengths(t *testing.T) {
+	for _, size := range []int{1, 7, 8, 9, splitChunkSize - 1, splitChunkSize + 9} {
+		secret := make([]byte, size)
+		if _, err := rand.Read(secret); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		out, err := Split(secret, 5, 3)
 		if err != nil {
 			t.Fatalf("err: %v", err)
 		}
 
-		x_vals := []uint8{1, 2, 3}
-		y_vals := []uint8{p.evaluate(1), p.evaluate(2), p.evaluate(3)}
-		out := interpolatePolynomial(x_vals, y_vals, 0)
-		if out != uint8(i) {
-			t.Fatalf("Bad: %v %d", out, i)
+		recomb, err := Combine([][]byte{out[4], out[0], out[2]})
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if !bytes.Equal(recomb, secret) {
+			t.Fatalf("bad: size %d", size)
+		}
+
+		// Compare with the byte by byte evaluation of the polynomials
+		x_vals := []uint8{out[0][size], out[1][size], out[2][size]}
+		for idx := 0; idx < size; idx++ {
+			y_vals := []uint8{out[0][idx], out[1][idx], out[2][idx]}
+			p, ok := berlekampWelch(x_vals, y_vals, 3)
+			if !ok || evaluate(p, out[3][size]) != out[3][idx] || p[0] != secret[idx] {
+				t.Fatalf("bad: size %d idx %d", size, idx)
+			}
+		}
+	}
+}
+
+func benchmarkSecret(b *testing.B, size int) []byte {
+	secret := make([]byte, size)
+	if _, err := rand.Read(secret); err != nil {
+		b.Fatalf("err: %v", err)
+	}
+	return secret
+}
+
+func BenchmarkSplit(b *testing.B) {
+	secret := benchmarkSecret(b, 4<<20)
+	b.SetBytes(int64(len(secret)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Split(secret, 9, 4); err != nil {
+			b.Fatalf("err: %v", err)
+		}
+	}
+}
+
+func BenchmarkCombine(b *testing.B) {
+	secret := benchmarkSecret(b, 4<<20)
+	out, err := Split(secret, 9, 4)
+	if err != nil {
+		b.Fatalf("err: %v", err)
+	}
+	b.SetBytes(int64(len(secret)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Combine(out[2:6]); err != nil {
+			b.Fatalf("err: %v", err)
 		}
 	}
 }